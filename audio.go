@@ -0,0 +1,31 @@
+package virtualbox
+
+// AudioConfig holds the audio device settings of a machine.
+type AudioConfig struct {
+	Driver     string // host audio driver, one of {none|null|pulse|alsa|oss|coreaudio|dsound}
+	Controller string // emulated audio controller, one of {ac97|hda|sb16}
+	In         bool   // enable audio input
+	Out        bool   // enable audio output
+}
+
+// SetAudio changes the audio device settings of the machine. A "none" driver
+// disables the audio device, regardless of the other settings.
+func (m *Machine) SetAudio(cfg AudioConfig) error {
+	if cfg.Driver == "" {
+		cfg.Driver = "none"
+	}
+	args := []string{"modifyvm", m.Name, "--audio", cfg.Driver}
+	if cfg.Driver != "none" {
+		if cfg.Controller != "" {
+			args = append(args, "--audiocontroller", cfg.Controller)
+		}
+		args = append(args,
+			"--audioin", bool2string(cfg.In),
+			"--audioout", bool2string(cfg.Out))
+	}
+	if err := Manage().run(args...); err != nil {
+		return err
+	}
+	m.Audio = cfg
+	return nil
+}
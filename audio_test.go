@@ -0,0 +1,35 @@
+package virtualbox
+
+import (
+	"testing"
+)
+
+func TestSetAudio(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("changing the audio device would change TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	ManageMock.EXPECT().run("modifyvm", VM, "--audio", "pulse", "--audiocontroller", "hda", "--audioin", "off", "--audioout", "on").
+		Return(nil).Times(1)
+	ManageMock.EXPECT().run("modifyvm", VM, "--audio", "none").Return(nil).Times(1)
+	ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+
+	cfg := AudioConfig{Driver: "pulse", Controller: "hda", Out: true}
+	m := &Machine{Name: VM}
+	if err := m.SetAudio(cfg); err != nil {
+		t.Fatal(err)
+	}
+	mm, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mm.Audio != cfg {
+		t.Fatalf("audio settings did not round-trip: %+v != %+v", mm.Audio, cfg)
+	}
+	if err := m.SetAudio(AudioConfig{}); err != nil {
+		t.Fatal(err)
+	}
+}
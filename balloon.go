@@ -0,0 +1,33 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var (
+	reBalloonUnsupported = regexp.MustCompile(`(?i)ballooning is not supported|does not support (?:memory )?ballooning|Guest Additions are not (?:installed|running)|guest object not available`)
+)
+
+var (
+	// ErrBalloonNotSupported is returned when the guest cannot inflate or
+	// deflate its memory balloon, usually because the Guest Additions are
+	// not running.
+	ErrBalloonNotSupported = errors.New("guest does not support memory ballooning")
+)
+
+// SetMemoryBalloon sets the size (in MB) of the memory balloon of the running
+// machine, which the guest hands back to the host. It requires the Guest
+// Additions.
+func (m *Machine) SetMemoryBalloon(sizeMB uint) error {
+	_, stderr, err := Manage().runOutErr("controlvm", m.Name, "guestmemoryballoon", fmt.Sprintf("%d", sizeMB))
+	if err != nil {
+		if reBalloonUnsupported.MatchString(stderr) {
+			return ErrBalloonNotSupported
+		}
+		return err
+	}
+	m.GuestMemoryBalloon = sizeMB
+	return nil
+}
@@ -0,0 +1,40 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetMemoryBalloon(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM is not guaranteed to run the Guest Additions")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("controlvm", "appliance", "guestmemoryballoon", "512").Return("", "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("controlvm", "appliance", "guestmemoryballoon", "1024").
+			Return("", "VBoxManage: error: Guest Additions are not installed or not running\n", errors.New("exit status 1")).Times(1),
+	)
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.GuestMemoryBalloon != 256 {
+		t.Fatalf("unexpected GuestMemoryBalloon %d", m.GuestMemoryBalloon)
+	}
+	if err := m.SetMemoryBalloon(512); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetMemoryBalloon(1024); err != ErrBalloonNotSupported {
+		t.Fatalf("expected ErrBalloonNotSupported, got %v", err)
+	}
+	if m.GuestMemoryBalloon != 512 {
+		t.Fatalf("unexpected GuestMemoryBalloon %d", m.GuestMemoryBalloon)
+	}
+}
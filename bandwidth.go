@@ -0,0 +1,35 @@
+package virtualbox
+
+import (
+	"fmt"
+)
+
+// BandwidthGroupType is the kind of traffic a bandwidth group limits.
+type BandwidthGroupType string
+
+const (
+	// BandwidthDisk is a BandwidthGroupType value.
+	BandwidthDisk = BandwidthGroupType("disk")
+	// BandwidthNetwork is a BandwidthGroupType value.
+	BandwidthNetwork = BandwidthGroupType("network")
+)
+
+// AddBandwidthGroup creates a bandwidth group limiting the disk or network
+// traffic of the storage media and NICs referencing it through their
+// BandwidthGroup. The limit is in MB/s by default, or takes a k, m or g
+// suffix, e.g. "20m".
+func (m *Machine) AddBandwidthGroup(name string, typ BandwidthGroupType, limit string) error {
+	if name == "" {
+		return fmt.Errorf("bandwidth group name is empty")
+	}
+	if typ != BandwidthDisk && typ != BandwidthNetwork {
+		return fmt.Errorf("invalid bandwidth group type %q", typ)
+	}
+	return Manage().run("bandwidthctl", m.Name, "add", name, "--type", string(typ), "--limit", limit)
+}
+
+// RemoveBandwidthGroup removes the named bandwidth group, which must no longer
+// be referenced.
+func (m *Machine) RemoveBandwidthGroup(name string) error {
+	return Manage().run("bandwidthctl", m.Name, "remove", name)
+}
@@ -0,0 +1,30 @@
+package virtualbox
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestBandwidthGroup(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("bandwidth groups would change the settings of TEST_VM")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("bandwidthctl", VM, "add", "slow-disk", "--type", "disk", "--limit", "20m").Return(nil).Times(1),
+		ManageMock.EXPECT().run("bandwidthctl", VM, "remove", "slow-disk").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM}
+	if err := m.AddBandwidthGroup("slow-disk", BandwidthDisk, "20m"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveBandwidthGroup("slow-disk"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddBandwidthGroup("slow-disk", BandwidthGroupType("usb"), "20m"); err == nil {
+		t.Fatal("expected an error for an invalid type")
+	}
+}
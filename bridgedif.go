@@ -0,0 +1,70 @@
+package virtualbox
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Keys never contain spaces or colons, while the interface names, e.g.
+	// 'Intel(R) Ethernet Connection (2) I219-V' on Windows or 'en0: Wi-Fi' on
+	// macOS, may contain both.
+	reBridgedIfLine = regexp.MustCompile(`^(\w+):\s*(.*?)\s*$`)
+)
+
+// BridgedInterface is a host network interface a bridged NIC can be attached
+// to, through its NIC.HostInterface.
+type BridgedInterface struct {
+	Name       string
+	GUID       string
+	IPAddress  net.IP
+	MediumType string
+	Status     string
+}
+
+// ListBridgedInterfaces returns the host network interfaces available for
+// bridged networking.
+func ListBridgedInterfaces() ([]BridgedInterface, error) {
+	out, err := Manage().runOut("list", "bridgedifs")
+	if err != nil {
+		return nil, err
+	}
+	s := bufio.NewScanner(strings.NewReader(out))
+	ifs := []BridgedInterface{}
+	bif := BridgedInterface{}
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			if bif.Name != "" {
+				ifs = append(ifs, bif)
+			}
+			bif = BridgedInterface{}
+			continue
+		}
+		res := reBridgedIfLine.FindStringSubmatch(line)
+		if res == nil {
+			continue
+		}
+		switch key, val := res[1], res[2]; key {
+		case "Name":
+			bif.Name = val
+		case "GUID":
+			bif.GUID = val
+		case "IPAddress":
+			bif.IPAddress = net.ParseIP(val)
+		case "MediumType":
+			bif.MediumType = val
+		case "Status":
+			bif.Status = val
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if bif.Name != "" {
+		ifs = append(ifs, bif)
+	}
+	return ifs, nil
+}
@@ -0,0 +1,32 @@
+package virtualbox
+
+import (
+	"testing"
+)
+
+func TestListBridgedInterfaces(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		bridgedIfsOut := ReadTestData("vboxmanage-list-bridgedifs-1.out")
+		ManageMock.EXPECT().runOut("list", "bridgedifs").Return(bridgedIfsOut, nil).Times(1)
+	}
+	ifs, err := ListBridgedInterfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", ifs)
+	if ManageMock != nil {
+		if len(ifs) != 2 {
+			t.Fatalf("expected 2 interfaces, got %d", len(ifs))
+		}
+		if ifs[0].Name != "en0: Wi-Fi (AirPort)" || ifs[0].IPAddress.String() != "192.168.1.23" || ifs[0].Status != "Up" {
+			t.Fatalf("unexpected interface %+v", ifs[0])
+		}
+		if ifs[1].Name != "Intel(R) Ethernet Connection (2) I219-V" || ifs[1].MediumType != "Ethernet" || ifs[1].Status != "Down" {
+			t.Fatalf("unexpected interface %+v", ifs[1])
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,50 @@
+package virtualbox
+
+import (
+	"fmt"
+)
+
+// Clipboard and drag and drop modes, naming the directions data may flow
+// between the host and the guest.
+const (
+	TransferDisabled      = "disabled"
+	TransferHostToGuest   = "hosttoguest"
+	TransferGuestToHost   = "guesttohost"
+	TransferBidirectional = "bidirectional"
+)
+
+// SetClipboardMode sets the direction of the shared clipboard, one of
+// {disabled|hosttoguest|guesttohost|bidirectional}. The mode of a running
+// machine is changed live.
+func (m *Machine) SetClipboardMode(mode string) error {
+	if err := m.setTransferMode(mode, []string{"clipboard", "mode"}, "--clipboard-mode"); err != nil {
+		return err
+	}
+	m.ClipboardMode = mode
+	return nil
+}
+
+// SetDragAndDropMode sets the direction of drag and drop, one of
+// {disabled|hosttoguest|guesttohost|bidirectional}. The mode of a running
+// machine is changed live.
+func (m *Machine) SetDragAndDropMode(mode string) error {
+	if err := m.setTransferMode(mode, []string{"draganddrop"}, "--draganddrop"); err != nil {
+		return err
+	}
+	m.DragAndDropMode = mode
+	return nil
+}
+
+func (m *Machine) setTransferMode(mode string, controlArgs []string, modifyOption string) error {
+	switch mode {
+	case TransferDisabled, TransferHostToGuest, TransferGuestToHost, TransferBidirectional:
+	default:
+		return fmt.Errorf("unknown transfer mode %q", mode)
+	}
+	switch m.State {
+	case Running, Paused:
+		args := append([]string{"controlvm", m.Name}, controlArgs...)
+		return Manage().run(append(args, mode)...)
+	}
+	return Manage().run("modifyvm", m.Name, modifyOption, mode)
+}
@@ -0,0 +1,40 @@
+package virtualbox
+
+import (
+	"testing"
+)
+
+func TestSetClipboardMode(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("changing the clipboard mode would change TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+	ManageMock.EXPECT().run("controlvm", "appliance", "clipboard", "mode", "disabled").Return(nil).Times(1)
+	ManageMock.EXPECT().run("controlvm", "appliance", "draganddrop", "bidirectional").Return(nil).Times(1)
+	ManageMock.EXPECT().run("modifyvm", "appliance", "--clipboard-mode", "hosttoguest").Return(nil).Times(1)
+
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ClipboardMode != TransferBidirectional || m.DragAndDropMode != TransferHostToGuest {
+		t.Fatalf("unexpected modes %q and %q", m.ClipboardMode, m.DragAndDropMode)
+	}
+	if err := m.SetClipboardMode(TransferDisabled); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetDragAndDropMode(TransferBidirectional); err != nil {
+		t.Fatal(err)
+	}
+	m.State = Poweroff
+	if err := m.SetClipboardMode(TransferHostToGuest); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetClipboardMode("sideways"); err == nil {
+		t.Fatal("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,37 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCPUHotplugDisabled is returned when plugging or unplugging a CPU of
+	// a machine without the CPUHOTPLUG flag.
+	ErrCPUHotplugDisabled = errors.New("CPU hot-plug is disabled")
+)
+
+// PlugCPU adds the virtual CPU id to the running machine, which must have the
+// CPUHOTPLUG flag. The boot CPU 0 is always present.
+func (m *Machine) PlugCPU(id int) error {
+	return m.hotplugCPU("plugcpu", id)
+}
+
+// UnplugCPU removes the virtual CPU id from the running machine, which must
+// have the CPUHOTPLUG flag. The boot CPU 0 cannot be removed.
+func (m *Machine) UnplugCPU(id int) error {
+	return m.hotplugCPU("unplugcpu", id)
+}
+
+func (m *Machine) hotplugCPU(op string, id int) error {
+	if m.Flag&CPUHOTPLUG == 0 {
+		return fmt.Errorf("cannot %s %d: machine %q: %w", op, id, m.Name, ErrCPUHotplugDisabled)
+	}
+	if err := m.checkState(op, Running); err != nil {
+		return err
+	}
+	if id < 1 {
+		return fmt.Errorf("cannot %s %d: CPU 0 is always present", op, id)
+	}
+	return Manage().run("controlvm", m.Name, op, fmt.Sprintf("%d", id))
+}
@@ -0,0 +1,40 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestHotplugCPU(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM is not guaranteed to be running with CPU hot-plug")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("controlvm", VM, "plugcpu", "3").Return(nil).Times(1),
+		ManageMock.EXPECT().run("controlvm", VM, "unplugcpu", "3").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Running, Flag: CPUHOTPLUG}
+	if err := m.PlugCPU(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UnplugCPU(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UnplugCPU(0); err == nil {
+		t.Fatal("expected an error for CPU 0")
+	}
+
+	m.State = Poweroff
+	if err := m.PlugCPU(3); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+	m.State, m.Flag = Running, 0
+	if err := m.PlugCPU(3); !errors.Is(err, ErrCPUHotplugDisabled) {
+		t.Fatalf("expected ErrCPUHotplugDisabled, got %v", err)
+	}
+}
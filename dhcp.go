@@ -41,19 +41,44 @@ func AddHostonlyDHCP(ifname string, d DHCP) error {
 	return addDHCP("--ifname", ifname, d)
 }
 
+// AddDHCPServer adds a DHCP server to the network named d.NetworkName.
+func AddDHCPServer(d DHCP) error {
+	return addDHCP("--netname", d.NetworkName, d)
+}
+
+// RemoveDHCPServer removes the DHCP server of the named network.
+func RemoveDHCPServer(netName string) error {
+	return Manage().run("dhcpserver", "remove", "--netname", netName)
+}
+
 // DHCPs gets all DHCP server settings in a map keyed by DHCP.NetworkName.
 func DHCPs() (map[string]*DHCP, error) {
+	dhcps, err := ListDHCPServers()
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]*DHCP{}
+	for _, dhcp := range dhcps {
+		m[dhcp.NetworkName] = dhcp
+	}
+	return m, nil
+}
+
+// ListDHCPServers gets all DHCP server settings.
+func ListDHCPServers() ([]*DHCP, error) {
 	out, err := Manage().runOut("list", "dhcpservers")
 	if err != nil {
 		return nil, err
 	}
 	s := bufio.NewScanner(strings.NewReader(out))
-	m := map[string]*DHCP{}
+	dhcps := []*DHCP{}
 	dhcp := &DHCP{}
 	for s.Scan() {
 		line := s.Text()
 		if line == "" {
-			m[dhcp.NetworkName] = dhcp
+			if dhcp.NetworkName != "" {
+				dhcps = append(dhcps, dhcp)
+			}
 			dhcp = &DHCP{}
 			continue
 		}
@@ -61,14 +86,15 @@ func DHCPs() (map[string]*DHCP, error) {
 		if res == nil {
 			continue
 		}
+		/* Keys were renamed in VirtualBox 6.1, support both. */
 		switch key, val := res[1], res[2]; key {
 		case "NetworkName":
 			dhcp.NetworkName = val
-		case "IP":
+		case "IP", "Dhcpd IP":
 			dhcp.IPv4.IP = net.ParseIP(val)
-		case "upperIPAddress":
+		case "upperIPAddress", "UpperIPAddress":
 			dhcp.UpperIP = net.ParseIP(val)
-		case "lowerIPAddress":
+		case "lowerIPAddress", "LowerIPAddress":
 			dhcp.LowerIP = net.ParseIP(val)
 		case "NetworkMask":
 			dhcp.IPv4.Mask = ParseIPv4Mask(val)
@@ -79,5 +105,8 @@ func DHCPs() (map[string]*DHCP, error) {
 	if err := s.Err(); err != nil {
 		return nil, err
 	}
-	return m, nil
+	if dhcp.NetworkName != "" {
+		dhcps = append(dhcps, dhcp)
+	}
+	return dhcps, nil
 }
@@ -1,6 +1,7 @@
 package virtualbox
 
 import (
+	"net"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -26,3 +27,44 @@ func TestDHCPs(t *testing.T) {
 
 	Teardown()
 }
+
+func TestDHCPServers(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("adding a DHCP server would change the host")
+	}
+	listDhcpServersOut := ReadTestData("vboxmanage-list-dhcpservers-2.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().run("dhcpserver", "add", "--netname", "backend", "--ip", "10.0.0.2", "--netmask", "255.255.255.0",
+			"--lowerip", "10.0.0.10", "--upperip", "10.0.0.100", "--disable").Return(nil).Times(1),
+		ManageMock.EXPECT().runOut("list", "dhcpservers").Return(listDhcpServersOut, nil).Times(1),
+		ManageMock.EXPECT().run("dhcpserver", "remove", "--netname", "backend").Return(nil).Times(1),
+	)
+
+	_, ipnet, _ := net.ParseCIDR("10.0.0.2/24")
+	ipnet.IP = net.ParseIP("10.0.0.2")
+	d := DHCP{
+		NetworkName: "backend",
+		IPv4:        *ipnet,
+		LowerIP:     net.ParseIP("10.0.0.10"),
+		UpperIP:     net.ParseIP("10.0.0.100"),
+	}
+	if err := AddDHCPServer(d); err != nil {
+		t.Fatal(err)
+	}
+	dhcps, err := ListDHCPServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dhcps) != 2 {
+		t.Fatalf("expected 2 DHCP servers, got %d", len(dhcps))
+	}
+	if got := dhcps[1]; got.NetworkName != "backend" || !got.LowerIP.Equal(d.LowerIP) || got.Enabled {
+		t.Fatalf("unexpected DHCP server %+v", got)
+	}
+	if err := RemoveDHCPServer("backend"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,48 @@
+package virtualbox
+
+import (
+	"fmt"
+)
+
+// DefaultCipher is the cipher used by EncryptMedium when none is given.
+const DefaultCipher = "AES-XTS256-PLAIN64"
+
+// EncryptMedium encrypts the unencrypted disk image at path with the password
+// of the machine. The name of the machine identifies the password, so one
+// AddEncryptionPassword call unlocks all its disks. The cipher defaults to
+// DefaultCipher when empty.
+func (m *Machine) EncryptMedium(path, password, cipher string) error {
+	if password == "" {
+		return fmt.Errorf("encryption password of medium %s is empty", path)
+	}
+	if cipher == "" {
+		cipher = DefaultCipher
+	}
+	return withPasswordFile(password, func(passwordFile string) error {
+		return Manage().run("encryptmedium", path,
+			"--newpassword", passwordFile,
+			"--cipher", cipher,
+			"--newpasswordid", m.Name,
+		)
+	})
+}
+
+// DecryptMedium decrypts the disk image at path, encrypted with the given
+// password.
+func (m *Machine) DecryptMedium(path, password string) error {
+	return withPasswordFile(password, func(passwordFile string) error {
+		return Manage().run("encryptmedium", path, "--oldpassword", passwordFile)
+	})
+}
+
+// AddEncryptionPassword hands the password of the encrypted disks of the
+// machine to VirtualBox. A machine started with encrypted disks is paused
+// until their password is given.
+func (m *Machine) AddEncryptionPassword(password string) error {
+	if err := m.checkState("add encryption password", Running, Paused); err != nil {
+		return err
+	}
+	return withPasswordFile(password, func(passwordFile string) error {
+		return Manage().run("controlvm", m.Name, "addencpassword", m.Name, passwordFile)
+	})
+}
@@ -0,0 +1,76 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+// expectPasswordFile returns a mock action checking that the argument at
+// index i is a file holding password.
+func expectPasswordFile(t *testing.T, i int, password string) func(args ...string) error {
+	return func(args ...string) error {
+		b, err := ioutil.ReadFile(args[i])
+		if err != nil {
+			return err
+		}
+		if string(b) != password {
+			t.Errorf("unexpected password %q", b)
+		}
+		return nil
+	}
+}
+
+func TestEncryptMedium(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("encrypting would need a disk image of TEST_VM")
+	}
+	var passwordFile string
+	gomock.InOrder(
+		ManageMock.EXPECT().run("encryptmedium", "disk.vdi", "--newpassword", gomock.Any(),
+			"--cipher", DefaultCipher, "--newpasswordid", VM).DoAndReturn(func(args ...string) error {
+			passwordFile = args[3]
+			return expectPasswordFile(t, 3, "s3cret")(args...)
+		}).Times(1),
+		ManageMock.EXPECT().run("encryptmedium", "disk.vdi", "--oldpassword", gomock.Any()).
+			DoAndReturn(expectPasswordFile(t, 3, "s3cret")).Times(1),
+	)
+	m := &Machine{Name: VM}
+	if err := m.EncryptMedium("disk.vdi", "s3cret", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(passwordFile); !os.IsNotExist(err) {
+		t.Fatalf("password file %s was not removed", passwordFile)
+	}
+	if err := m.DecryptMedium("disk.vdi", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.EncryptMedium("disk.vdi", "", ""); err == nil {
+		t.Fatal("expected an error for an empty password")
+	}
+}
+
+func TestAddEncryptionPassword(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM has no encrypted disk")
+	}
+	ManageMock.EXPECT().run("controlvm", VM, "addencpassword", VM, gomock.Any()).
+		DoAndReturn(expectPasswordFile(t, 4, "s3cret")).Times(1)
+	m := &Machine{Name: VM, State: Paused}
+	if err := m.AddEncryptionPassword("s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	m.State = Poweroff
+	if err := m.AddEncryptionPassword("s3cret"); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnsureMachine creates the machine described by spec when no machine has its
+// name, and otherwise updates the CPUs, memory, video memory, flags and NICs of
+// the existing machine to match spec. Settings left to zero in spec are not
+// changed, nor are the NICs of the machine beyond those of spec. The OS type,
+// base folder, groups and UUID of spec are only used to create the machine. An
+// existing machine can only be updated when it is powered off.
+//
+// created reports whether the machine was created rather than updated.
+func EnsureMachine(spec Machine) (m *Machine, created bool, err error) {
+	if spec.Name == "" {
+		return nil, false, fmt.Errorf("machine name is empty")
+	}
+
+	m, err = GetMachine(spec.Name)
+	switch {
+	case err == ErrMachineNotExist:
+		m, err = CreateMachineOpts(CreateOptions{
+			Name:       spec.Name,
+			BaseFolder: spec.BaseFolder,
+			OSType:     spec.OSType,
+			Groups:     spec.Groups,
+			UUID:       spec.UUID,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		created = true
+	case err != nil:
+		return nil, false, err
+	case !m.differs(&spec):
+		return m, false, nil
+	default:
+		if err := m.checkState("update", Poweroff, Aborted); err != nil {
+			return nil, false, err
+		}
+	}
+
+	desired := m.Copy()
+	if spec.CPUs != 0 {
+		desired.CPUs = spec.CPUs
+	}
+	if spec.Memory != 0 {
+		desired.Memory = spec.Memory
+	}
+	if spec.VRAM != 0 {
+		desired.VRAM = spec.VRAM
+	}
+	if spec.Flag != 0 {
+		desired.Flag = spec.Flag
+	}
+	for i, nic := range spec.NICs {
+		if i < len(desired.NICs) {
+			desired.NICs[i] = nic
+		} else {
+			desired.NICs = append(desired.NICs, nic)
+		}
+	}
+	if err := desired.Modify(); err != nil {
+		return nil, created, err
+	}
+	return desired, created, nil
+}
+
+// differs tests whether the settings of spec managed by EnsureMachine differ
+// from those of the machine.
+func (m *Machine) differs(spec *Machine) bool {
+	if spec.CPUs != 0 && spec.CPUs != m.CPUs ||
+		spec.Memory != 0 && spec.Memory != m.Memory ||
+		spec.VRAM != 0 && spec.VRAM != m.VRAM ||
+		spec.Flag != 0 && spec.Flag != m.Flag {
+		return true
+	}
+	if len(spec.NICs) > len(m.NICs) {
+		return true
+	}
+	for i, nic := range spec.NICs {
+		cur := m.NICs[i]
+		if nic.Network != cur.Network || nic.Hardware != cur.Hardware || nic.HostInterface != cur.HostInterface {
+			return true
+		}
+		if nic.MacAddr != "" && nic.MacAddr != "auto" && !strings.EqualFold(nic.MacAddr, cur.MacAddr) {
+			return true
+		}
+		if nic.PromiscMode != "" && nic.PromiscMode != cur.PromiscMode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestEnsureMachineUnchanged(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the settings of TEST_VM are unknown")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+
+	spec := Machine{
+		Name:   "go-virtualbox",
+		CPUs:   1,
+		Memory: 1024,
+		NICs:   []NIC{{Network: NICNetNAT, Hardware: IntelPro1000MTDesktop, MacAddr: "080027ee1df7"}},
+	}
+	m, created, err := EnsureMachine(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || m.Name != "go-virtualbox" {
+		t.Fatalf("unexpected machine %+v, created: %v", m, created)
+	}
+
+	// The machine is saved, so it cannot be updated.
+	spec.CPUs = 2
+	if _, _, err := EnsureMachine(spec); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestEnsureMachineCreate(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("creating would leave a machine behind")
+	}
+	vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-4.out"),
+		`ostype="Other/Unknown"`, `ostype="Ubuntu (64-bit)"`, 1)
+	var modifyArgs []string
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", "fresh", "--machinereadable").
+			Return("", "VBoxManage: error: Could not find a registered machine named 'fresh'\n", errors.New("exit status 1")).Times(1),
+		ManageMock.EXPECT().runOutErr("createvm", "--name", "fresh", "--register", "--ostype", "Ubuntu_64").
+			Return("Virtual machine 'fresh' is created and registered.\n"+
+				"UUID: 0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d\n"+
+				"Settings file: '/home/user/VirtualBox VMs/fresh/fresh.vbox'\n", "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d", "--machinereadable").
+			Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().run(gomock.Any()).DoAndReturn(func(args ...string) error {
+			modifyArgs = args
+			return nil
+		}).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "fresh", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+	)
+
+	_, created, err := EnsureMachine(Machine{Name: "fresh", OSType: "Ubuntu_64", CPUs: 2, Memory: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Fatal("expected the machine to be created")
+	}
+	for option, want := range map[string]string{
+		"--ostype": "Ubuntu_64",
+		"--cpus":   "2",
+		"--memory": "2048",
+		"--vram":   "8",
+	} {
+		if got := optionValue(modifyArgs, option); got != want {
+			t.Errorf("expected %s %s, got %q", option, want, got)
+		}
+	}
+}
+
+func TestEnsureMachineOSType(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the settings of TEST_VM are unknown")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-4.out")
+	ManageMock.EXPECT().runOutErr("showvminfo", "fresh", "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+
+	// The OS type of an existing machine is left alone.
+	m, created, err := EnsureMachine(Machine{Name: "fresh", OSType: "Ubuntu_64", CPUs: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || m.OSType != "Other" {
+		t.Fatalf("unexpected machine %+v, created: %v", m, created)
+	}
+}
+
+func TestEnsureMachineUpdate(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("updating would change the settings of TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-4.out")
+	var modifyArgs []string
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", "fresh", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().run(gomock.Any()).DoAndReturn(func(args ...string) error {
+			modifyArgs = args
+			return nil
+		}).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "fresh", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+	)
+
+	_, created, err := EnsureMachine(Machine{Name: "fresh", CPUs: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Fatal("expected the machine to be updated")
+	}
+	// The OS type is sent back by ID, not by the description of showvminfo.
+	for option, want := range map[string]string{
+		"--ostype": "Other",
+		"--cpus":   "2",
+		"--memory": "128",
+	} {
+		if got := optionValue(modifyArgs, option); got != want {
+			t.Errorf("expected %s %s, got %q", option, want, got)
+		}
+	}
+}
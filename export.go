@@ -0,0 +1,68 @@
+package virtualbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// OVFFormat is the version of the OVF standard used to export a machine.
+type OVFFormat string
+
+const (
+	// OVF09 exports in the legacy OVF 0.9 format.
+	OVF09 = OVFFormat("ovf09")
+	// OVF10 exports in the OVF 1.0 format.
+	OVF10 = OVFFormat("ovf10")
+	// OVF20 exports in the OVF 2.0 format.
+	OVF20 = OVFFormat("ovf20")
+)
+
+// ExportOptions holds the optional settings of an appliance export.
+type ExportOptions struct {
+	Format      OVFFormat // VirtualBox default when empty
+	Manifest    bool      // write a manifest of the exported files
+	ProductName string
+	Vendor      string
+	Version     string
+	Progress    ProgressFunc // called as the export progresses, if not nil
+}
+
+// Export exports the machine as an appliance to outputPath, whose extension
+// (.ova or .ovf) selects the packaging. The machine must be powered off.
+func (m *Machine) Export(ctx context.Context, outputPath string, opts ExportOptions) error {
+	if err := m.checkState("export", Poweroff, Aborted); err != nil {
+		return err
+	}
+
+	args := []string{"export", m.Name, "--output", outputPath}
+	if opts.Format != "" {
+		args = append(args, "--"+string(opts.Format))
+	}
+	if opts.Manifest {
+		args = append(args, "--manifest")
+	}
+	if opts.ProductName != "" || opts.Vendor != "" || opts.Version != "" {
+		args = append(args, "--vsys", "0")
+		if opts.ProductName != "" {
+			args = append(args, "--product", opts.ProductName)
+		}
+		if opts.Vendor != "" {
+			args = append(args, "--vendor", opts.Vendor)
+		}
+		if opts.Version != "" {
+			args = append(args, "--version", opts.Version)
+		}
+	}
+
+	if err := Manage().setOpts(withContext(ctx), withProgress(opts.Progress)).run(args...); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		return fmt.Errorf("export of machine %q did not produce %s: %w", m.Name, outputPath, err)
+	}
+	return nil
+}
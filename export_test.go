@@ -0,0 +1,35 @@
+package virtualbox
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	Setup(t)
+
+	dir, err := ioutil.TempDir("", "go-virtualbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ova := filepath.Join(dir, "appliance.ova")
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().run("export", VM, "--output", ova, "--ovf20", "--manifest",
+			"--vsys", "0", "--product", "Appliance", "--version", "1.0").
+			DoAndReturn(func(args ...string) error {
+				return ioutil.WriteFile(ova, nil, 0600)
+			}).Times(1)
+	}
+	m := &Machine{Name: VM, State: Poweroff}
+	opts := ExportOptions{Format: OVF20, Manifest: true, ProductName: "Appliance", Version: "1.0"}
+	if err := m.Export(context.Background(), ova, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	Teardown()
+}
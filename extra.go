@@ -1,5 +1,18 @@
 package virtualbox
 
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reExtraDataLine = regexp.MustCompile(`^Key: (.*?), Value: (.*)$`)
+)
+
 // SetExtra sets extra data. Name could be "global"|<uuid>|<vmname>
 func SetExtra(name, key, val string) error {
 	return Manage().run("setextradata", name, key, val)
@@ -9,3 +22,87 @@ func SetExtra(name, key, val string) error {
 func DelExtra(name, key string) error {
 	return Manage().run("setextradata", name, key)
 }
+
+// SetGlobalExtraData attaches a custom string to VirtualBox, rather than to a
+// machine.
+func SetGlobalExtraData(key, val string) error {
+	return Manage().run("setextradata", "global", key, val)
+}
+
+// GetGlobalExtraData retrieves a custom string attached to VirtualBox. It
+// returns nil when the key is not set.
+func GetGlobalExtraData(key string) (*string, error) {
+	return getExtraData(context.Background(), "global", key)
+}
+
+// DeleteGlobalExtraData removes a custom string attached to VirtualBox.
+func DeleteGlobalExtraData(key string) error {
+	return Manage().run("setextradata", "global", key)
+}
+
+// getExtraData retrieves the custom string key of name, which is "global" or
+// a machine.
+func getExtraData(ctx context.Context, name, key string) (*string, error) {
+	value, err := Manage().setOpts(withContext(ctx)).runOut("getextradata", name, key)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	value = strings.TrimSpace(value)
+	/* 'getextradata get' returns 0 even when the key is not found,
+	so we need to check stdout for this case */
+	if strings.HasPrefix(value, "No value set") {
+		return nil, nil
+	}
+	trimmed := strings.TrimPrefix(value, "Value: ")
+	return &trimmed, nil
+}
+
+// ListExtraData returns all the custom strings attached to the VM, keyed by
+// name.
+func (m *Machine) ListExtraData() (map[string]string, error) {
+	out, err := Manage().runOut("getextradata", m.Name, "enumerate")
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]string{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reExtraDataLine.FindStringSubmatch(strings.TrimRight(s.Text(), "\r"))
+		if res == nil {
+			continue
+		}
+		data[res[1]] = res[2]
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SetExtraDataInt attaches a custom integer to the VM.
+func (m *Machine) SetExtraDataInt(key string, v int) error {
+	return m.SetExtraData(key, strconv.Itoa(v))
+}
+
+// GetExtraDataInt retrieves a custom integer from the VM. It returns nil when
+// the key is not set.
+func (m *Machine) GetExtraDataInt(key string) (*int, error) {
+	val, err := m.GetExtraData(key)
+	if err != nil || val == nil {
+		return nil, err
+	}
+	v, err := strconv.Atoi(*val)
+	if err != nil {
+		return nil, fmt.Errorf("extra data %s of machine %q is not an integer: %w", key, m.Name, err)
+	}
+	return &v, nil
+}
+
+// SetGUIFlag sets the boolean VirtualBox GUI setting GUI/<name> of the VM,
+// e.g. "ShowMiniToolBar" or "Fullscreen".
+func (m *Machine) SetGUIFlag(name string, on bool) error {
+	return m.SetExtraData("GUI/"+name, strconv.FormatBool(on))
+}
@@ -0,0 +1,97 @@
+package virtualbox
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestListExtraData(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().runOut("getextradata", VM, "enumerate").
+			Return("Key: GUI/LastCloseAction, Value: PowerOff\n"+
+				"Key: owner, Value: ci, nightly\n", nil).Times(1)
+	}
+	m := &Machine{Name: VM}
+	data, err := m.ListExtraData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%v", data)
+	if ManageMock != nil {
+		if len(data) != 2 || data["GUI/LastCloseAction"] != "PowerOff" || data["owner"] != "ci, nightly" {
+			t.Fatalf("unexpected extra data %v", data)
+		}
+	}
+
+	Teardown()
+}
+
+func TestExtraDataInt(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM extra data would be changed")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("setextradata", VM, "retries", "3").Return(nil).Times(1),
+		ManageMock.EXPECT().runOut("getextradata", VM, "retries").Return("Value: 3\n", nil).Times(1),
+		ManageMock.EXPECT().runOut("getextradata", VM, "missing").Return("No value set!\n", nil).Times(1),
+		ManageMock.EXPECT().runOut("getextradata", VM, "owner").Return("Value: ci\n", nil).Times(1),
+		ManageMock.EXPECT().run("setextradata", VM, "GUI/ShowMiniToolBar", "false").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM}
+	if err := m.SetExtraDataInt("retries", 3); err != nil {
+		t.Fatal(err)
+	}
+	v, err := m.GetExtraDataInt("retries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v == nil || *v != 3 {
+		t.Fatalf("unexpected value %v", v)
+	}
+	if v, err := m.GetExtraDataInt("missing"); err != nil || v != nil {
+		t.Fatalf("expected no value, got %v, %v", v, err)
+	}
+	if _, err := m.GetExtraDataInt("owner"); err == nil {
+		t.Fatal("expected an error for a non-integer value")
+	}
+	if err := m.SetGUIFlag("ShowMiniToolBar", false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGlobalExtraData(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("global extra data would change the host settings")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("setextradata", "global", "fleet/region", "eu-west").Return(nil).Times(1),
+		ManageMock.EXPECT().runOut("getextradata", "global", "fleet/region").Return("Value: eu-west\n", nil).Times(1),
+		ManageMock.EXPECT().run("setextradata", "global", "fleet/region").Return(nil).Times(1),
+		ManageMock.EXPECT().runOut("getextradata", "global", "fleet/region").Return("No value set!\n", nil).Times(1),
+	)
+	if err := SetGlobalExtraData("fleet/region", "eu-west"); err != nil {
+		t.Fatal(err)
+	}
+	val, err := GetGlobalExtraData("fleet/region")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val == nil || *val != "eu-west" {
+		t.Fatalf("unexpected value %v", val)
+	}
+	if err := DeleteGlobalExtraData("fleet/region"); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := GetGlobalExtraData("fleet/region"); err != nil || val != nil {
+		t.Fatalf("expected no value, got %v, %v", val, err)
+	}
+}
@@ -0,0 +1,42 @@
+package virtualbox
+
+import (
+	"context"
+)
+
+// GuestAdditionsVersion returns the version of the Guest Additions running in
+// the guest, e.g. "6.1.26". It returns ErrGuestAdditionsNotRunning when the
+// Guest Additions did not report their version.
+func (m *Machine) GuestAdditionsVersion() (string, error) {
+	version, err := m.GetGuestProperty("/VirtualBox/GuestAdd/Version")
+	if err == ErrPropertyNotExist {
+		return "", ErrGuestAdditionsNotRunning
+	}
+	return version, err
+}
+
+// UpdateGuestAdditions updates the Guest Additions of the running guest to
+// the version of the host, from the ISO shipped with VirtualBox. The Guest
+// Additions must already be running.
+func (m *Machine) UpdateGuestAdditions(ctx context.Context, creds GuestCredentials) error {
+	if err := m.checkState("update guest additions", Running); err != nil {
+		return err
+	}
+	if _, err := m.GuestAdditionsVersion(); err != nil {
+		return err
+	}
+
+	args := []string{"guestcontrol", m.Name, "updatega"}
+	args = append(args, creds.args()...)
+	_, stderr, err := Manage().setOpts(withContext(ctx)).runOutErr(args...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if reGuestAdditionsMissing.MatchString(stderr) {
+			return ErrGuestAdditionsNotRunning
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package virtualbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestGuestAdditionsVersion(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the Guest Additions of TEST_VM are unknown")
+	}
+	ManageMock.EXPECT().isGuest().Return(false).AnyTimes()
+	gomock.InOrder(
+		ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("Value: 6.1.26\n", nil).Times(1),
+		ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("No value set!\n", nil).Times(1),
+	)
+	m := &Machine{Name: VM}
+	version, err := m.GuestAdditionsVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "6.1.26" {
+		t.Fatalf("unexpected version %q", version)
+	}
+	if _, err := m.GuestAdditionsVersion(); err != ErrGuestAdditionsNotRunning {
+		t.Fatalf("expected ErrGuestAdditionsNotRunning, got %v", err)
+	}
+}
+
+func TestUpdateGuestAdditions(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM is not guaranteed to be running")
+	}
+	creds := GuestCredentials{Username: "vagrant", Password: "vagrant"}
+	ManageMock.EXPECT().isGuest().Return(false).AnyTimes()
+	gomock.InOrder(
+		ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("Value: 6.1.26\n", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("guestcontrol", VM, "updatega", "--username", "vagrant", "--password", "vagrant").Return("", "", nil).Times(1),
+		ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("No value set!\n", nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Running}
+	if err := m.UpdateGuestAdditions(context.Background(), creds); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UpdateGuestAdditions(context.Background(), creds); err != ErrGuestAdditionsNotRunning {
+		t.Fatalf("expected ErrGuestAdditionsNotRunning, got %v", err)
+	}
+	m.State = Poweroff
+	if err := m.UpdateGuestAdditions(context.Background(), creds); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
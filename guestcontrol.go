@@ -0,0 +1,124 @@
+package virtualbox
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+var (
+	reVBoxManageError       = regexp.MustCompile(`VBoxManage: error:`)
+	reGuestAdditionsMissing = regexp.MustCompile(`guest execution service is not (?:ready|available)|Guest Additions are not (?:installed|running)`)
+)
+
+var (
+	// ErrGuestAdditionsNotRunning is returned when an operation requires the
+	// Guest Additions, but they are not installed or not running in the guest.
+	ErrGuestAdditionsNotRunning = errors.New("guest additions are not running")
+)
+
+// GuestCredentials holds the guest account used to run guest control commands.
+type GuestCredentials struct {
+	Username string
+	Password string
+	Domain   string
+}
+
+func (c GuestCredentials) args() []string {
+	args := []string{"--username", c.Username, "--password", c.Password}
+	if c.Domain != "" {
+		args = append(args, "--domain", c.Domain)
+	}
+	return args
+}
+
+// GuestSession binds a machine to the credentials of a guest account.
+type GuestSession struct {
+	Machine     *Machine
+	Credentials GuestCredentials
+}
+
+// GuestSession returns a session to run guest control commands in the
+// machine with the given credentials.
+func (m *Machine) GuestSession(creds GuestCredentials) *GuestSession {
+	return &GuestSession{Machine: m, Credentials: creds}
+}
+
+// Run executes exe with args in the guest. See Machine.GuestRun.
+func (s *GuestSession) Run(ctx context.Context, exe string, args ...string) (string, string, int, error) {
+	return s.Machine.GuestRun(ctx, s.Credentials, exe, args)
+}
+
+// CopyTo copies hostPath to guestPath in the guest. See Machine.CopyToGuest.
+func (s *GuestSession) CopyTo(ctx context.Context, hostPath, guestPath string, recursive bool) error {
+	return s.Machine.CopyToGuest(ctx, s.Credentials, hostPath, guestPath, recursive)
+}
+
+// CopyFrom copies guestPath in the guest to hostPath. See Machine.CopyFromGuest.
+func (s *GuestSession) CopyFrom(ctx context.Context, guestPath, hostPath string, recursive bool) error {
+	return s.Machine.CopyFromGuest(ctx, s.Credentials, guestPath, hostPath, recursive)
+}
+
+// GuestRun executes exe with args in the guest, which requires the Guest
+// Additions, and waits for it to complete. It returns the stdout, stderr
+// and exit code of the guest process. The error is only set when the
+// process could not be run.
+func (m *Machine) GuestRun(ctx context.Context, creds GuestCredentials, exe string, args []string) (stdout, stderr string, exitCode int, err error) {
+	argv := []string{"guestcontrol", m.Name, "run"}
+	argv = append(argv, creds.args()...)
+	argv = append(argv, "--exe", exe, "--wait-stdout", "--wait-stderr")
+	// The first argument after '--' is the argv[0] of the guest process.
+	argv = append(argv, "--", exe)
+	argv = append(argv, args...)
+
+	stdout, stderr, err = Manage().setOpts(withContext(ctx)).runOutErr(argv...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return stdout, stderr, -1, ctx.Err()
+		}
+		/* VBoxManage exits with the exit code of the guest process, unless
+		it failed to run it, in which case it prints an error. */
+		var ce *CommandError
+		if errors.As(err, &ce) && !reVBoxManageError.MatchString(stderr) {
+			return stdout, stderr, ce.ExitCode, nil
+		}
+		if reGuestAdditionsMissing.MatchString(stderr) {
+			return stdout, stderr, -1, ErrGuestAdditionsNotRunning
+		}
+		return stdout, stderr, -1, err
+	}
+	return stdout, stderr, 0, nil
+}
+
+// CopyToGuest copies the host file hostPath to guestPath in the guest, which
+// requires the Guest Additions. Directories are copied when recursive is true.
+func (m *Machine) CopyToGuest(ctx context.Context, creds GuestCredentials, hostPath, guestPath string, recursive bool) error {
+	return m.guestCopy(ctx, "copyto", creds, hostPath, guestPath, recursive)
+}
+
+// CopyFromGuest copies the guest file guestPath to hostPath on the host, which
+// requires the Guest Additions. Directories are copied when recursive is true.
+func (m *Machine) CopyFromGuest(ctx context.Context, creds GuestCredentials, guestPath, hostPath string, recursive bool) error {
+	return m.guestCopy(ctx, "copyfrom", creds, guestPath, hostPath, recursive)
+}
+
+func (m *Machine) guestCopy(ctx context.Context, op string, creds GuestCredentials, src, dst string, recursive bool) error {
+	args := []string{"guestcontrol", m.Name, op}
+	args = append(args, creds.args()...)
+	if recursive {
+		args = append(args, "--recursive")
+	}
+	args = append(args, src, dst)
+
+	_, stderr, err := Manage().setOpts(withContext(ctx)).runOutErr(args...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if reGuestAdditionsMissing.MatchString(stderr) {
+			return ErrGuestAdditionsNotRunning
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package virtualbox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestGuestRun(t *testing.T) {
+	Setup(t)
+
+	creds := GuestCredentials{Username: "vagrant", Password: "vagrant"}
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "run", "--username", "vagrant", "--password", "vagrant",
+				"--exe", "/bin/ls", "--wait-stdout", "--wait-stderr", "--", "/bin/ls", "/").
+				Return("bin\nboot\ndev\n", "", nil),
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "run", "--username", "vagrant", "--password", "vagrant",
+				"--exe", "/bin/ls", "--wait-stdout", "--wait-stderr", "--", "/bin/ls", "/nonexistent").
+				Return("", "/bin/ls: cannot access '/nonexistent': No such file or directory\n", &CommandError{ExitCode: 2}),
+		)
+	}
+	session := (&Machine{Name: VM}).GuestSession(creds)
+	stdout, _, code, err := session.Run(context.Background(), "/bin/ls", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("stdout=%q", stdout)
+	if code != 0 {
+		t.Fatalf("unexpected exit code %d", code)
+	}
+
+	_, stderr, code, err := session.Run(context.Background(), "/bin/ls", "/nonexistent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("stderr=%q", stderr)
+	if code != 2 {
+		t.Fatalf("unexpected exit code %d", code)
+	}
+
+	Teardown()
+}
+
+func TestCopyToGuest(t *testing.T) {
+	Setup(t)
+
+	creds := GuestCredentials{Username: "vagrant", Password: "vagrant"}
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "copyto", "--username", "vagrant", "--password", "vagrant",
+				"--recursive", "testdata", "/tmp/testdata").Return("", "", nil),
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "copyfrom", "--username", "vagrant", "--password", "vagrant",
+				"/etc/hostname", "/tmp/hostname").
+				Return("", "VBoxManage: error: The guest execution service is not ready (yet)\n", &CommandError{ExitCode: 1}),
+		)
+	}
+	m := &Machine{Name: VM}
+	if err := m.CopyToGuest(context.Background(), creds, "testdata", "/tmp/testdata", true); err != nil {
+		t.Fatal(err)
+	}
+	if ManageMock != nil {
+		err := m.CopyFromGuest(context.Background(), creds, "/etc/hostname", "/tmp/hostname", false)
+		if err != ErrGuestAdditionsNotRunning {
+			t.Fatalf("expected ErrGuestAdditionsNotRunning, got %v", err)
+		}
+	}
+
+	Teardown()
+}
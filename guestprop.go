@@ -1,11 +1,15 @@
 package virtualbox
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 )
 
 // GuestProperty holds key, value and associated flags.
@@ -14,9 +18,17 @@ type GuestProperty struct {
 	Value string
 }
 
+var (
+	// ErrPropertyNotExist is returned when the guest property is not set.
+	ErrPropertyNotExist = errors.New("guest property does not exist")
+)
+
 var (
 	getRegexp  = regexp.MustCompile("(?m)^Value: ([^,]*)$")
 	waitRegexp = regexp.MustCompile("^Name: ([^,]*), value: ([^,]*), flags:.*$")
+
+	reWaitTimeout = regexp.MustCompile("Time out or interruption while waiting")
+	reEnumLine    = regexp.MustCompile(`^Name: (.*?), value: (.*), timestamp: \d+, flags:.*$`)
 )
 
 // SetGuestProperty writes a VirtualBox guestproperty to the given value.
@@ -27,7 +39,8 @@ func SetGuestProperty(vm string, prop string, val string) error {
 	return Manage().run("guestproperty", "set", vm, prop, val)
 }
 
-// GetGuestProperty reads a VirtualBox guestproperty.
+// GetGuestProperty reads a VirtualBox guestproperty. It returns
+// ErrPropertyNotExist when the property is not set.
 func GetGuestProperty(vm string, prop string) (string, error) {
 	var out string
 	var err error
@@ -41,6 +54,9 @@ func GetGuestProperty(vm string, prop string) (string, error) {
 	}
 	out = strings.TrimSpace(out)
 	Debug("out (trimmed): '%s'", out)
+	if strings.HasPrefix(out, "No value set") {
+		return "", ErrPropertyNotExist
+	}
 	var match = getRegexp.FindStringSubmatch(out)
 	Debug("match:", match)
 	if len(match) != 2 {
@@ -134,3 +150,70 @@ func DeleteGuestProperty(vm string, prop string) error {
 	}
 	return Manage().run("guestproperty", "delete", vm, prop)
 }
+
+// SetGuestProperty writes the guest property key of the machine.
+func (m *Machine) SetGuestProperty(key, val string) error {
+	return SetGuestProperty(m.Name, key, val)
+}
+
+// GetGuestProperty reads the guest property key of the machine. It returns
+// ErrPropertyNotExist when the property is not set.
+func (m *Machine) GetGuestProperty(key string) (string, error) {
+	return GetGuestProperty(m.Name, key)
+}
+
+// guestPropertyWaitTimeout bounds each 'guestproperty wait' call of
+// WaitForGuestProperty, so the context is checked regularly.
+var guestPropertyWaitTimeout = 1 * time.Second
+
+// WaitForGuestProperty blocks until the guest property key of the machine
+// has a value, and returns it. It returns the context error when ctx is done
+// first.
+func (m *Machine) WaitForGuestProperty(ctx context.Context, key string) (string, error) {
+	for {
+		val, err := m.GetGuestProperty(key)
+		if err == nil {
+			return val, nil
+		}
+		if err != ErrPropertyNotExist {
+			return "", err
+		}
+
+		_, stderr, err := Manage().setOpts(withContext(ctx)).runOutErr("guestproperty", "wait", m.Name, key,
+			"--timeout", fmt.Sprintf("%d", guestPropertyWaitTimeout.Milliseconds()))
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if err != nil && !reWaitTimeout.MatchString(stderr) {
+			return "", err
+		}
+	}
+}
+
+// EnumerateGuestProperties returns the guest properties of the machine, keyed
+// by name. When patterns are given, only the properties whose name matches
+// one of the glob patterns are returned.
+func (m *Machine) EnumerateGuestProperties(patterns ...string) (map[string]string, error) {
+	args := []string{"guestproperty", "enumerate", m.Name}
+	if len(patterns) > 0 {
+		// VBoxManage separates alternative patterns with '|'.
+		args = append(args, "--patterns", strings.Join(patterns, "|"))
+	}
+	out, err := Manage().runOut(args...)
+	if err != nil {
+		return nil, err
+	}
+	props := map[string]string{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reEnumLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		props[res[1]] = res[2]
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
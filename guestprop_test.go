@@ -1,6 +1,7 @@
 package virtualbox
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
@@ -193,3 +194,85 @@ func TestWaitGuestPropertiesQuit(t *testing.T) {
 
 	Teardown()
 }
+
+func TestMachineGuestProperty(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().isGuest().Return(false),
+			ManageMock.EXPECT().run("guestproperty", "set", VM, "test_key", "test_val").Return(nil),
+			ManageMock.EXPECT().isGuest().Return(false),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "test_key").Return("Value: test_val\n", nil),
+			ManageMock.EXPECT().isGuest().Return(false),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "test_missing").Return("No value set!\n", nil),
+		)
+	}
+	m := &Machine{Name: VM}
+	if err := m.SetGuestProperty("test_key", "test_val"); err != nil {
+		t.Fatal(err)
+	}
+	val, err := m.GetGuestProperty("test_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != "test_val" {
+		t.Fatalf("unexpected value %q", val)
+	}
+	if _, err := m.GetGuestProperty("test_missing"); err != ErrPropertyNotExist {
+		t.Fatalf("expected ErrPropertyNotExist, got %v", err)
+	}
+
+	Teardown()
+}
+
+func TestWaitForGuestProperty(t *testing.T) {
+	Setup(t)
+
+	key := "/VirtualBox/GuestInfo/Net/0/V4/IP"
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().isGuest().Return(false),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, key).Return("No value set!\n", nil),
+			ManageMock.EXPECT().runOutErr("guestproperty", "wait", VM, key, "--timeout", "1000").
+				Return("", "VBoxManage: error: Time out or interruption while waiting for a notification\n", errors.New("exit status 2")),
+			ManageMock.EXPECT().isGuest().Return(false),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, key).Return("Value: 10.0.2.15\n", nil),
+		)
+	}
+	m := &Machine{Name: VM}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ip, err := m.WaitForGuestProperty(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("ip=%s", ip)
+	if ManageMock != nil && ip != "10.0.2.15" {
+		t.Fatalf("unexpected value %q", ip)
+	}
+
+	Teardown()
+}
+
+func TestEnumerateGuestProperties(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		enumerateOut := ReadTestData("vboxmanage-guestproperty-enumerate-1.out")
+		ManageMock.EXPECT().runOut("guestproperty", "enumerate", VM, "--patterns", "/VirtualBox/*|test_*").Return(enumerateOut, nil)
+	}
+	m := &Machine{Name: VM}
+	props, err := m.EnumerateGuestProperties("/VirtualBox/*", "test_*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", props)
+	if ManageMock != nil {
+		if len(props) != 5 || props["/VirtualBox/GuestAdd/Version"] != "6.1.26" || props["test_key"] != "a, b, c" {
+			t.Fatalf("unexpected properties %+v", props)
+		}
+	}
+
+	Teardown()
+}
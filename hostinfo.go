@@ -0,0 +1,72 @@
+package virtualbox
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+)
+
+// HostInfo holds the host system information as detected by VirtualBox.
+type HostInfo struct {
+	ProcessorCount    uint
+	CoreCount         uint
+	MemorySizeMB      uint
+	MemoryAvailableMB uint
+	OS                string
+	OSVersion         string
+	// CPUFeatures tells whether the host processor supports each feature,
+	// keyed by the VirtualBox name, e.g. "HW virtualization" or "PAE".
+	CPUFeatures map[string]bool
+}
+
+// GetHostInfo returns the host system information.
+func GetHostInfo(ctx context.Context) (*HostInfo, error) {
+	out, err := Manage().setOpts(withContext(ctx)).runOut("list", "hostinfo")
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	info := &HostInfo{CPUFeatures: map[string]bool{}}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		key, val := res[1], res[2]
+		if strings.HasPrefix(key, "Processor supports ") {
+			info.CPUFeatures[strings.TrimPrefix(key, "Processor supports ")] = val == "yes"
+			continue
+		}
+		switch key {
+		case "Processor count":
+			info.ProcessorCount, err = parseHostInfoUint(val)
+		case "Processor core count":
+			info.CoreCount, err = parseHostInfoUint(val)
+		case "Memory size":
+			info.MemorySizeMB, err = parseHostInfoUint(strings.TrimSuffix(val, " MByte"))
+		case "Memory available":
+			info.MemoryAvailableMB, err = parseHostInfoUint(strings.TrimSuffix(val, " MByte"))
+		case "Operating system":
+			info.OS = val
+		case "Operating system version":
+			info.OSVersion = val
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func parseHostInfoUint(s string) (uint, error) {
+	n, err := strconv.ParseUint(s, 10, 32)
+	return uint(n), err
+}
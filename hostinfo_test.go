@@ -0,0 +1,33 @@
+package virtualbox
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetHostInfo(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		hostInfoOut := ReadTestData("vboxmanage-list-hostinfo-1.out")
+		ManageMock.EXPECT().runOut("list", "hostinfo").Return(hostInfoOut, nil).Times(1)
+	}
+	info, err := GetHostInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", info)
+	if info.ProcessorCount == 0 || info.MemorySizeMB == 0 {
+		t.Fatalf("incomplete host info %+v", info)
+	}
+	if ManageMock != nil {
+		if info.ProcessorCount != 8 || info.CoreCount != 4 || info.MemorySizeMB != 15934 || info.OSVersion != "5.11.0-38-generic" {
+			t.Fatalf("unexpected host info %+v", info)
+		}
+		if !info.CPUFeatures["HW virtualization"] || info.CPUFeatures["nested HW virtualization"] {
+			t.Fatalf("unexpected CPU features %v", info.CPUFeatures)
+		}
+	}
+
+	Teardown()
+}
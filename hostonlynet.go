@@ -96,19 +96,52 @@ func (n *HostonlyNet) Config() error {
 
 }
 
+// RemoveHostonlyNet removes the named host-only network.
+func RemoveHostonlyNet(name string) error {
+	return Manage().run("hostonlyif", "remove", name)
+}
+
+// ConfigureHostonlyNet sets the IPv4 address and netmask (in IP form, e.g.
+// 255.255.255.0) of the named host-only network.
+func ConfigureHostonlyNet(name, ip, netmask string) error {
+	n := &HostonlyNet{Name: name}
+	if n.IPv4.IP = net.ParseIP(ip); n.IPv4.IP == nil {
+		return fmt.Errorf("invalid IP address %q", ip)
+	}
+	if n.IPv4.Mask = ParseIPv4Mask(netmask); n.IPv4.Mask == nil {
+		return fmt.Errorf("invalid netmask %q", netmask)
+	}
+	return n.Config()
+}
+
 // HostonlyNets gets all host-only networks in a  map keyed by HostonlyNet.NetworkName.
 func HostonlyNets() (map[string]*HostonlyNet, error) {
+	nets, err := ListHostonlyNets()
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]*HostonlyNet{}
+	for _, n := range nets {
+		m[n.NetworkName] = n
+	}
+	return m, nil
+}
+
+// ListHostonlyNets gets all host-only networks.
+func ListHostonlyNets() ([]*HostonlyNet, error) {
 	out, err := Manage().runOut("list", "hostonlyifs")
 	if err != nil {
 		return nil, err
 	}
 	s := bufio.NewScanner(strings.NewReader(out))
-	m := map[string]*HostonlyNet{}
+	nets := []*HostonlyNet{}
 	n := &HostonlyNet{}
 	for s.Scan() {
 		line := s.Text()
 		if line == "" {
-			m[n.NetworkName] = n
+			if n.Name != "" {
+				nets = append(nets, n)
+			}
 			n = &HostonlyNet{}
 			continue
 		}
@@ -152,5 +185,8 @@ func HostonlyNets() (map[string]*HostonlyNet, error) {
 	if err := s.Err(); err != nil {
 		return nil, err
 	}
-	return m, nil
+	if n.Name != "" {
+		nets = append(nets, n)
+	}
+	return nets, nil
 }
@@ -1,6 +1,7 @@
 package virtualbox
 
 import (
+	"runtime"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -25,3 +26,46 @@ func TestHostonlyNets(t *testing.T) {
 
 	Teardown()
 }
+
+func TestHostonlyNetLifecycle(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("creating a host-only network would change the host")
+	}
+	if runtime.GOOS == osWindows {
+		t.Skip("host-only networks are configured with netsh on Windows")
+	}
+	listHostOnlyIfsOut := ReadTestData("vboxmanage-list-hostonlyifs-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOut("hostonlyif", "create").
+			Return("0%...10%...20%...30%...40%...50%...60%...70%...80%...90%...100%\n"+
+				"Interface 'vboxnet0' was successfully created\n", nil).Times(1),
+		ManageMock.EXPECT().run("hostonlyif", "ipconfig", "vboxnet0", "--ip", "192.168.56.1", "--netmask", "255.255.255.0").
+			Return(nil).Times(1),
+		ManageMock.EXPECT().runOut("list", "hostonlyifs").Return(listHostOnlyIfsOut, nil).Times(1),
+		ManageMock.EXPECT().run("hostonlyif", "remove", "vboxnet0").Return(nil).Times(1),
+	)
+
+	n, err := CreateHostonlyNet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureHostonlyNet(n.Name, "192.168.56.1", "255.255.255.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureHostonlyNet(n.Name, "192.168.56", "255.255.255.0"); err == nil {
+		t.Fatal("expected an error for an invalid IP address")
+	}
+	nets, err := ListHostonlyNets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nets) != 1 || nets[0].Name != n.Name || nets[0].IPv4.IP.String() != "192.168.56.1" {
+		t.Fatalf("unexpected host-only networks %+v", nets)
+	}
+	if err := RemoveHostonlyNet(n.Name); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,99 @@
 package virtualbox
 
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reImportOSType = regexp.MustCompile(`^\s*\d+: Suggested OS type: "(.*)"`)
+	reImportVMName = regexp.MustCompile(`^\s*\d+: (?:Suggested VM name |VM name specified with --vmname: )"(.*)"`)
+	reImportCPUs   = regexp.MustCompile(`^\s*\d+: (?:Number of CPUs|No\. of CPUs specified with --cpus): (\d+)`)
+	reImportMemory = regexp.MustCompile(`^\s*\d+: Guest memory(?: specified with --memory)?: (\d+) MB`)
+)
+
 //ImportOV imports ova or ovf from the given path
 func ImportOV(path string) error {
 	return Manage().run("import", path)
 }
+
+// ImportOptions holds the optional settings of an appliance import.
+type ImportOptions struct {
+	VMName   string       // name of the imported machine, suggested by the appliance when empty
+	CPUs     uint         // number of virtual CPUs, from the appliance when zero
+	Memory   uint         // main memory (in MB), from the appliance when zero
+	DryRun   bool         // only return the machine that would be imported
+	Progress ProgressFunc // called as the import progresses, if not nil
+}
+
+// ImportMachine imports the first virtual system of the appliance at ovaPath
+// and returns the imported machine. With DryRun, nothing is imported and the
+// returned machine only holds the settings VirtualBox would use.
+func ImportMachine(ctx context.Context, ovaPath string, opts ImportOptions) (*Machine, error) {
+	args := []string{"import", ovaPath}
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if opts.VMName != "" || opts.CPUs > 0 || opts.Memory > 0 {
+		args = append(args, "--vsys", "0")
+		if opts.VMName != "" {
+			args = append(args, "--vmname", opts.VMName)
+		}
+		if opts.CPUs > 0 {
+			args = append(args, "--cpus", fmt.Sprintf("%d", opts.CPUs))
+		}
+		if opts.Memory > 0 {
+			args = append(args, "--memory", fmt.Sprintf("%d", opts.Memory))
+		}
+	}
+
+	out, err := Manage().setOpts(withContext(ctx), withProgress(opts.Progress)).runOut(args...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	/* VBoxManage describes the virtual systems it imports, read the first one. */
+	m := New()
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "Virtual system 1:") {
+			break
+		}
+		if res := reImportOSType.FindStringSubmatch(line); res != nil {
+			m.OSType = res[1]
+		} else if res := reImportVMName.FindStringSubmatch(line); res != nil {
+			m.Name = res[1]
+		} else if res := reImportCPUs.FindStringSubmatch(line); res != nil {
+			n, err := strconv.ParseUint(res[1], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			m.CPUs = uint(n)
+		} else if res := reImportMemory.FindStringSubmatch(line); res != nil {
+			n, err := strconv.ParseUint(res[1], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			m.Memory = uint(n)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if m.Name == "" {
+		return nil, fmt.Errorf("could not find the name of the machine imported from %s", ovaPath)
+	}
+
+	if opts.DryRun {
+		return m, nil
+	}
+	return GetMachine(m.Name)
+}
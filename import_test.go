@@ -0,0 +1,26 @@
+package virtualbox
+
+import (
+	"context"
+	"testing"
+)
+
+func TestImportMachineDryRun(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("no appliance to import with TEST_VM")
+	}
+	importOut := ReadTestData("vboxmanage-import-1.out")
+	ManageMock.EXPECT().runOut("import", "/tmp/appliance.ova", "--dry-run", "--vsys", "0", "--vmname", "imported", "--cpus", "2").
+		Return(importOut, nil).Times(1)
+	opts := ImportOptions{VMName: "imported", CPUs: 2, DryRun: true}
+	m, err := ImportMachine(context.Background(), "/tmp/appliance.ova", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "imported" || m.OSType != "Ubuntu_64" || m.CPUs != 2 || m.Memory != 1024 {
+		t.Fatalf("unexpected machine %+v", m)
+	}
+}
@@ -0,0 +1,118 @@
+package virtualbox
+
+import (
+	"fmt"
+)
+
+// keyboardPutStringVersion is the first VirtualBox version supporting
+// 'controlvm keyboardputstring'.
+var keyboardPutStringVersion = Version{Major: 6, Minor: 1}
+
+const (
+	scanCodeLeftShift = 0x2a
+	scanCodeBreak     = 0x80 // set on a make code to release the key
+)
+
+// scanKey is the key typing a character on a US keyboard.
+type scanKey struct {
+	code  byte // PC scan code set 1 make code
+	shift bool
+}
+
+// scanKeys maps the characters SendKeys can type to their key.
+var scanKeys = func() map[rune]scanKey {
+	keys := map[rune]scanKey{
+		'\b': {code: 0x0e},
+		'\t': {code: 0x0f},
+		'\n': {code: 0x1c},
+		' ':  {code: 0x39},
+	}
+	// Rows of the US keyboard, with their first make code.
+	rows := []struct {
+		code           byte
+		lower, shifted string
+	}{
+		{0x02, "1234567890-=", "!@#$%^&*()_+"},
+		{0x10, "qwertyuiop[]", "QWERTYUIOP{}"},
+		{0x1e, "asdfghjkl;'`", `ASDFGHJKL:"~`},
+		{0x2b, `\zxcvbnm,./`, "|ZXCVBNM<>?"},
+	}
+	for _, row := range rows {
+		for i, c := range row.lower {
+			keys[c] = scanKey{code: row.code + byte(i)}
+		}
+		for i, c := range row.shifted {
+			keys[c] = scanKey{code: row.code + byte(i), shift: true}
+		}
+	}
+	return keys
+}()
+
+// SendScanCodes sends the given PC scan codes (set 1) to the keyboard of the
+// running machine. Releasing a key takes its own break code, e.g. 0x1e 0x9e
+// to press and release 'a'.
+func (m *Machine) SendScanCodes(codes ...byte) error {
+	if err := m.checkState("send scan codes", Running); err != nil {
+		return err
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	args := []string{"controlvm", m.Name, "keyboardputscancode"}
+	for _, code := range codes {
+		args = append(args, fmt.Sprintf("%02x", code))
+	}
+	return Manage().run(args...)
+}
+
+// SendKeys types text on the keyboard of the running machine, e.g. to drive
+// a boot menu or an installer without Guest Additions. The guest keyboard is
+// assumed to have a US layout. Only printable ASCII characters, tabs,
+// newlines and backspaces can be typed.
+func (m *Machine) SendKeys(text string) error {
+	codes, err := scanCodes(text)
+	if err != nil {
+		return err
+	}
+	return m.SendScanCodes(codes...)
+}
+
+// TypeString types s on the keyboard of the running machine. With VirtualBox
+// 6.1 or later, VirtualBox converts the string itself, which handles any
+// character of the guest keyboard layout. Older versions fall back to
+// SendKeys.
+func (m *Machine) TypeString(s string) error {
+	if err := m.checkState("type string", Running); err != nil {
+		return err
+	}
+	v, err := managerVersion()
+	if err != nil {
+		return err
+	}
+	if v.Compare(keyboardPutStringVersion) < 0 {
+		return m.SendKeys(s)
+	}
+	if s == "" {
+		return nil
+	}
+	return Manage().run("controlvm", m.Name, "keyboardputstring", s)
+}
+
+// scanCodes returns the make and break codes typing text.
+func scanCodes(text string) ([]byte, error) {
+	var codes []byte
+	for _, c := range text {
+		key, ok := scanKeys[c]
+		if !ok {
+			return nil, fmt.Errorf("cannot type %q with scan codes", c)
+		}
+		if key.shift {
+			codes = append(codes, scanCodeLeftShift)
+		}
+		codes = append(codes, key.code, key.code|scanCodeBreak)
+		if key.shift {
+			codes = append(codes, scanCodeLeftShift|scanCodeBreak)
+		}
+	}
+	return codes, nil
+}
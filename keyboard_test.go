@@ -0,0 +1,73 @@
+package virtualbox
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestScanCodes(t *testing.T) {
+	tests := []struct {
+		text  string
+		codes []byte
+	}{
+		{"a", []byte{0x1e, 0x9e}},
+		{"A", []byte{0x2a, 0x1e, 0x9e, 0xaa}},
+		{"1 ?\n", []byte{0x02, 0x82, 0x39, 0xb9, 0x2a, 0x35, 0xb5, 0xaa, 0x1c, 0x9c}},
+		{`\|`, []byte{0x2b, 0xab, 0x2a, 0x2b, 0xab, 0xaa}},
+	}
+	for _, tt := range tests {
+		codes, err := scanCodes(tt.text)
+		if err != nil {
+			t.Errorf("scanCodes(%q): %v", tt.text, err)
+		} else if !bytes.Equal(codes, tt.codes) {
+			t.Errorf("scanCodes(%q) = % x, want % x", tt.text, codes, tt.codes)
+		}
+	}
+	if _, err := scanCodes("é"); err == nil {
+		t.Error("expected an error for a non-ASCII character")
+	}
+}
+
+func TestSendKeys(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM is not guaranteed to be running")
+	}
+	ManageMock.EXPECT().run("controlvm", VM, "keyboardputscancode", "2a", "23", "a3", "aa", "17", "97").Return(nil).Times(1)
+	m := &Machine{Name: VM, State: Running}
+	if err := m.SendKeys("Hi"); err != nil {
+		t.Fatal(err)
+	}
+	m.State = Poweroff
+	if err := m.SendScanCodes(0x1c, 0x9c); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestTypeString(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM is not guaranteed to be running")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().runOut("--version").Return("6.1.26r145957\n", nil).Times(1),
+		ManageMock.EXPECT().run("controlvm", VM, "keyboardputstring", "ks=cdrom:/ks.cfg").Return(nil).Times(1),
+		ManageMock.EXPECT().runOut("--version").Return("6.0.24r139119\n", nil).Times(1),
+		ManageMock.EXPECT().run("controlvm", VM, "keyboardputscancode", "1e", "9e").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Running}
+	if err := m.TypeString("ks=cdrom:/ks.cfg"); err != nil {
+		t.Fatal(err)
+	}
+	resetVersion()
+	if err := m.TypeString("a"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,5 +1,9 @@
 package virtualbox
 
+import (
+	"time"
+)
+
 // LogFunc is the signature to log traces.
 type LogFunc func(string, ...interface{})
 
@@ -7,3 +11,7 @@ func noLog(string, ...interface{}) {}
 
 // Debug is the Logger currently in use.
 var Debug LogFunc = noLog
+
+// CommandLogFunc is the signature to log the VirtualBox commands, called after
+// each command with its arguments, how long it ran and its error, if any.
+type CommandLogFunc func(args []string, duration time.Duration, err error)
@@ -3,8 +3,11 @@ package virtualbox
 import (
 	"bufio"
 	"context"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -26,6 +29,92 @@ const (
 	Saved = MachineState("saved")
 	// Aborted is a MachineState value.
 	Aborted = MachineState("aborted")
+	// AbortedSaved is a MachineState value, for a machine which crashed
+	// while having a saved state, as of VirtualBox 7.
+	AbortedSaved = MachineState("aborted-saved")
+	// Starting is a MachineState value.
+	Starting = MachineState("starting")
+	// Stopping is a MachineState value.
+	Stopping = MachineState("stopping")
+	// Saving is a MachineState value.
+	Saving = MachineState("saving")
+	// Restoring is a MachineState value.
+	Restoring = MachineState("restoring")
+)
+
+// IsTransitional tests whether the machine is changing from a state to
+// another, e.g. while starting or saving its state.
+func (s MachineState) IsTransitional() bool {
+	switch s {
+	case Starting, Stopping, Saving, Restoring:
+		return true
+	}
+	return false
+}
+
+// IsRunning tests whether the machine is running or starting.
+func (s MachineState) IsRunning() bool {
+	switch s {
+	case Running, Starting, Restoring:
+		return true
+	}
+	return false
+}
+
+// Firmware is the firmware used to boot the VM.
+type Firmware string
+
+const (
+	// FirmwareBIOS is a Firmware value.
+	FirmwareBIOS = Firmware("bios")
+	// FirmwareEFI is a Firmware value.
+	FirmwareEFI = Firmware("efi")
+	// FirmwareEFI32 is a Firmware value.
+	FirmwareEFI32 = Firmware("efi32")
+	// FirmwareEFI64 is a Firmware value.
+	FirmwareEFI64 = Firmware("efi64")
+)
+
+// ParavirtProvider is the paravirtualization interface exposed to the guest.
+type ParavirtProvider string
+
+const (
+	// ParavirtNone is a ParavirtProvider value.
+	ParavirtNone = ParavirtProvider("none")
+	// ParavirtDefault is a ParavirtProvider value.
+	ParavirtDefault = ParavirtProvider("default")
+	// ParavirtLegacy is a ParavirtProvider value.
+	ParavirtLegacy = ParavirtProvider("legacy")
+	// ParavirtMinimal is a ParavirtProvider value.
+	ParavirtMinimal = ParavirtProvider("minimal")
+	// ParavirtHyperV is a ParavirtProvider value, for Windows guests.
+	ParavirtHyperV = ParavirtProvider("hyperv")
+	// ParavirtKVM is a ParavirtProvider value, for Linux guests.
+	ParavirtKVM = ParavirtProvider("kvm")
+)
+
+// Chipset is the chipset emulated for the guest.
+type Chipset string
+
+const (
+	// ChipsetPIIX3 is a Chipset value.
+	ChipsetPIIX3 = Chipset("piix3")
+	// ChipsetICH9 is a Chipset value, for guests with many CPUs or PCIe devices.
+	ChipsetICH9 = Chipset("ich9")
+)
+
+// GraphicsController is the graphics card emulated for the guest.
+type GraphicsController string
+
+const (
+	// GraphicsNone is a GraphicsController value.
+	GraphicsNone = GraphicsController("none")
+	// GraphicsVBoxVGA is a GraphicsController value, the legacy controller.
+	GraphicsVBoxVGA = GraphicsController("vboxvga")
+	// GraphicsVMSVGA is a GraphicsController value, for modern Linux guests.
+	GraphicsVMSVGA = GraphicsController("vmsvga")
+	// GraphicsVBoxSVGA is a GraphicsController value, for modern Windows guests.
+	GraphicsVBoxSVGA = GraphicsController("vboxsvga")
 )
 
 // Flag is an active VM configuration toggle
@@ -47,8 +136,114 @@ const (
 	VTXVPID
 	VTXUX
 	ACCELERATE3D
+	NESTEDHWVIRT
+	ACCELERATE2D
 )
 
+// flagOptions lists the VBoxManage option of each Flag, which is also its key
+// in the machine readable VM info, and the name of its constant. Modify only
+// sends the recent options when their flag is set or was read from the
+// machine, as older VirtualBox versions reject them.
+var flagOptions = []struct {
+	flag   Flag
+	option string
+	name   string
+	recent bool
+}{
+	{ACPI, "acpi", "ACPI", false},
+	{IOAPIC, "ioapic", "IOAPIC", false},
+	{RTCUSEUTC, "rtcuseutc", "RTCUSEUTC", false},
+	{CPUHOTPLUG, "cpuhotplug", "CPUHOTPLUG", false},
+	{PAE, "pae", "PAE", false},
+	{LONGMODE, "longmode", "LONGMODE", false},
+	{HPET, "hpet", "HPET", false},
+	{HWVIRTEX, "hwvirtex", "HWVIRTEX", false},
+	{TRIPLEFAULTRESET, "triplefaultreset", "TRIPLEFAULTRESET", false},
+	{NESTEDPAGING, "nestedpaging", "NESTEDPAGING", false},
+	{LARGEPAGES, "largepages", "LARGEPAGES", false},
+	{VTXVPID, "vtxvpid", "VTXVPID", false},
+	{VTXUX, "vtxux", "VTXUX", false},
+	{ACCELERATE3D, "accelerate3d", "ACCELERATE3D", false},
+	{NESTEDHWVIRT, "nested-hw-virt", "NESTEDHWVIRT", true},
+	{ACCELERATE2D, "accelerate2dvideo", "ACCELERATE2D", true},
+}
+
+var (
+	// StrictFlags makes Modify check the flags of the machine with
+	// Flag.Validate before changing them.
+	StrictFlags bool
+	// ErrInvalidFlags is returned when a combination of flags is invalid.
+	ErrInvalidFlags = errors.New("invalid flags")
+)
+
+// flagRequirements lists the flags which only work along with another one.
+var flagRequirements = []struct {
+	flag, requires Flag
+}{
+	{LONGMODE, PAE},
+	{NESTEDPAGING, HWVIRTEX},
+	{LARGEPAGES, NESTEDPAGING},
+	{VTXVPID, HWVIRTEX},
+	{VTXUX, HWVIRTEX},
+	{NESTEDHWVIRT, HWVIRTEX},
+	{CPUHOTPLUG, IOAPIC},
+}
+
+// Validate checks that the flags do not contradict each other, e.g. VTXVPID
+// without HWVIRTEX. It returns an error wrapping ErrInvalidFlags otherwise.
+func (f Flag) Validate() error {
+	for _, r := range flagRequirements {
+		if f&r.flag == r.flag && f&r.requires != r.requires {
+			return fmt.Errorf("%w: %s requires %s", ErrInvalidFlags, r.flag, r.requires)
+		}
+	}
+	return nil
+}
+
+// String returns the names of the set flags joined by '|', e.g.
+// "ACPI|IOAPIC|PAE", or "0" when no flag is set.
+func (f Flag) String() string {
+	if f == 0 {
+		return "0"
+	}
+	var names []string
+	for _, o := range flagOptions {
+		if f&o.flag == o.flag {
+			names = append(names, o.name)
+			f &^= o.flag
+		}
+	}
+	if f != 0 {
+		names = append(names, fmt.Sprintf("Flag(%#x)", int(f)))
+	}
+	return strings.Join(names, "|")
+}
+
+// ParseFlags parses flag names joined by '|', as returned by Flag.String.
+// Names are case-insensitive.
+func ParseFlags(s string) (Flag, error) {
+	var f Flag
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return f, nil
+	}
+	for _, name := range strings.Split(s, "|") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, o := range flagOptions {
+			if strings.EqualFold(name, o.name) {
+				f |= o.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown flag %q", name)
+		}
+	}
+	return f, nil
+}
+
 // Convert bool to "on"/"off"
 func bool2string(b bool) string {
 	if b {
@@ -64,27 +259,74 @@ func (f Flag) Get(o Flag) string {
 
 // Machine information.
 type Machine struct {
-	Name       string
-	Firmware   string
-	UUID       string
-	State      MachineState
-	CPUs       uint
-	Memory     uint // main memory (in MB)
-	VRAM       uint // video memory (in MB)
-	CfgFile    string
-	BaseFolder string
-	OSType     string
-	Flag       Flag
-	BootOrder  []string // max 4 slots, each in {none|floppy|dvd|disk|net}
-	NICs       []NIC
+	Name               string
+	Firmware           Firmware
+	UUID               string
+	State              MachineState
+	CPUs               uint
+	CPUExecutionCap    uint               // max host CPU time of each CPU (in %, 1-100), unchanged when 0
+	Memory             uint               // main memory (in MB)
+	GuestMemoryBalloon uint               // memory balloon size (in MB)
+	VRAM               uint               // video memory (in MB), unchanged when 0
+	MonitorCount       uint               // unchanged when 0
+	GraphicsController GraphicsController // unchanged when empty
+	CfgFile            string
+	BaseFolder         string
+	OSType             string
+	Groups             []string         // slash-delimited paths, e.g. /Production/web
+	ParavirtProvider   ParavirtProvider // unchanged when empty
+	Chipset            Chipset          // unchanged when empty
+	TPMType            TPMType          // unchanged when empty, requires VirtualBox 7
+	TPMLocation        string           // socket of the 'swtpm' TPM, unchanged when empty
+	Flag               Flag
+	BootOrder          []string // max 4 slots, each in {none|floppy|dvd|disk|net}
+	NICs               []NIC
+	VRDE               VRDEConfig
+	Audio              AudioConfig
+	Recording          RecordingConfig
+	ClipboardMode      string // one of {disabled|hosttoguest|guesttohost|bidirectional}
+	DragAndDropMode    string // one of {disabled|hosttoguest|guesttohost|bidirectional}
+	USBFilters         []USBFilter
+	SerialPorts        []SerialPortConfig // n-th serial port at index n-1
+
+	readFlags Flag // flags whose option was read from the machine, set or not
 }
 
 // New creates a new machine.
 func New() *Machine {
 	return &Machine{
 		BootOrder: make([]string, 0, 4),
-		NICs:      make([]NIC, 0, 4),
+		NICs:      make([]NIC, 0, MaxNICs),
+	}
+}
+
+// Copy returns a deep copy of the machine, which can be changed without
+// affecting m.
+func (m *Machine) Copy() *Machine {
+	c := *m
+	c.Groups = copyStrings(m.Groups)
+	c.BootOrder = copyStrings(m.BootOrder)
+	if m.NICs != nil {
+		c.NICs = make([]NIC, len(m.NICs), cap(m.NICs))
+		copy(c.NICs, m.NICs)
 	}
+	if m.USBFilters != nil {
+		c.USBFilters = append([]USBFilter{}, m.USBFilters...)
+	}
+	if m.SerialPorts != nil {
+		c.SerialPorts = append([]SerialPortConfig{}, m.SerialPorts...)
+	}
+	return &c
+}
+
+// copyStrings returns a copy of s keeping its capacity, nil when s is nil.
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	c := make([]string, len(s), cap(s))
+	copy(c, s)
+	return c
 }
 
 // Refresh reloads the machine information.
@@ -101,22 +343,111 @@ func (m *Machine) Refresh() error {
 	return nil
 }
 
+// checkState returns an ErrInvalidState error when the machine is not in one
+// of the given states.
+func (m *Machine) checkState(op string, states ...MachineState) error {
+	for _, state := range states {
+		if m.State == state {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot %s: machine %q is %s: %w", op, m.Name, m.State, ErrInvalidState)
+}
+
+// WaitForState refreshes the machine every poll interval until it is in the
+// target state. It returns the context error when ctx is done first.
+func (m *Machine) WaitForState(ctx context.Context, target MachineState, poll time.Duration) error {
+	for {
+		if err := m.Refresh(); err != nil {
+			return err
+		}
+		if m.State == target {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+// WatchInterval is how often Watch polls the machine state.
+var WatchInterval = 1 * time.Second
+
+// Watch sends the new state of the machine each time it changes, and closes
+// the returned channel when ctx is done. VBoxManage has no event stream for
+// state changes, so Watch polls the state every WatchInterval; changes that
+// last less than that may be missed. The channel is also closed when the
+// state can no longer be read, e.g. when the machine was unregistered.
+//
+// Watch works on a copy of the machine, m itself is not refreshed.
+func (m *Machine) Watch(ctx context.Context) (<-chan MachineState, error) {
+	w := m.Copy()
+	if err := w.Refresh(); err != nil {
+		return nil, err
+	}
+	states := make(chan MachineState)
+	go func() {
+		defer close(states)
+		state := w.State
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(WatchInterval):
+			}
+			if err := w.Refresh(); err != nil {
+				Debug("Watch(): machine %s: %v", w.Name, err)
+				return
+			}
+			if w.State == state {
+				continue
+			}
+			state = w.State
+			select {
+			case <-ctx.Done():
+				return
+			case states <- state:
+			}
+		}
+	}()
+	return states, nil
+}
+
 // Start the machine, and return the underlying error when unable to do so.
+// Starting a running machine does nothing, and a machine in a state it cannot
+// be started from, e.g. stuck, returns an error wrapping ErrInvalidState.
 func (m *Machine) Start() error {
-	var args []string
+	return m.StartContext(context.Background())
+}
 
+// StartContext starts the machine like Start, bounded by the given context.
+func (m *Machine) StartContext(ctx context.Context) error {
+	if err := m.settle(ctx); err != nil {
+		return err
+	}
+
+	var args []string
 	switch m.State {
 	case Paused:
 		args = []string{"controlvm", m.Name, "resume"}
-	case Poweroff, Saved, Aborted:
+	case Poweroff, Saved, Aborted, AbortedSaved:
 		args = []string{"startvm", m.Name, "--type", "headless"}
+	case Running:
+		return nil
+	default:
+		return m.checkState("start", Paused, Poweroff, Saved, Aborted, AbortedSaved)
 	}
 
-	_, msg, err := Run(context.Background(), args...)
+	_, msg, err := Run(ctx, args...)
 	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return errors.New(msg)
 	}
-
+	m.State = Running
 	return nil
 }
 
@@ -132,7 +463,7 @@ func (m *Machine) Save() error {
 		if err := m.Start(); err != nil {
 			return err
 		}
-	case Poweroff, Aborted, Saved:
+	case Poweroff, Aborted, AbortedSaved, Saved:
 		return nil
 	}
 	return Manage().run("controlvm", m.Name, "savestate")
@@ -141,28 +472,85 @@ func (m *Machine) Save() error {
 // Pause pauses the execution of the machine.
 func (m *Machine) Pause() error {
 	switch m.State {
-	case Paused, Poweroff, Aborted, Saved:
+	case Paused, Poweroff, Aborted, AbortedSaved, Saved:
 		return nil
 	}
 	return Manage().run("controlvm", m.Name, "pause")
 }
 
-// Stop gracefully stops the machine.
+// Sleep presses the ACPI sleep button of the machine, to suspend the guest
+// operating system rather than saving the machine state like Save. The
+// machine must be running.
+func (m *Machine) Sleep() error {
+	if err := m.checkState("sleep", Running); err != nil {
+		return err
+	}
+	return Manage().run("controlvm", m.Name, "acpisleepbutton")
+}
+
+// StopTimeout is how long Stop waits for the guest to honor the ACPI power
+// button before forcefully powering off the machine. Zero waits forever.
+var StopTimeout = 60 * time.Second
+
+// stopPollInterval is how often Stop checks whether the machine is stopped.
+var stopPollInterval = 1 * time.Second
+
+// Stop gracefully stops the machine. If the machine is still running after
+// StopTimeout, it is forcefully powered off.
 func (m *Machine) Stop() error {
+	return m.StopContext(context.Background())
+}
+
+// StopContext gracefully stops the machine like Stop. It gives up waiting for
+// the machine to stop and returns the context error when ctx is done.
+func (m *Machine) StopContext(ctx context.Context) error {
+	if err := m.settle(ctx); err != nil {
+		return err
+	}
 	switch m.State {
-	case Poweroff, Aborted, Saved:
+	case Poweroff, Aborted, AbortedSaved, Saved:
 		return nil
 	case Paused:
-		if err := m.Start(); err != nil {
+		if err := m.StartContext(ctx); err != nil {
 			return err
 		}
 	}
 
-	for m.State != Poweroff { // busy wait until the machine is stopped
-		if err := Manage().run("controlvm", m.Name, "acpipowerbutton"); err != nil {
+	/* Press the power button only once: repeated presses confuse some guests,
+	which might for instance show a shutdown dialog again. */
+	if err := Manage().setOpts(withContext(ctx)).run("controlvm", m.Name, "acpipowerbutton"); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	deadline := time.Now().Add(StopTimeout)
+	for m.State != Poweroff { // poll until the machine is stopped
+		if StopTimeout > 0 && time.Now().After(deadline) {
+			Debug("Stop(): machine %s ignored the ACPI power button, powering off", m.Name)
+			return m.PoweroffContext(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(stopPollInterval):
+		}
+		if err := m.Refresh(); err != nil {
 			return err
 		}
-		time.Sleep(1 * time.Second)
+	}
+	return nil
+}
+
+// settle refreshes the machine until it is no longer in a transitional
+// state, as VirtualBox rejects most commands until the transition completes.
+func (m *Machine) settle(ctx context.Context) error {
+	for m.State.IsTransitional() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(stopPollInterval):
+		}
 		if err := m.Refresh(); err != nil {
 			return err
 		}
@@ -172,25 +560,44 @@ func (m *Machine) Stop() error {
 
 // Poweroff forcefully stops the machine. State is lost and might corrupt the disk image.
 func (m *Machine) Poweroff() error {
+	return m.PoweroffContext(context.Background())
+}
+
+// PoweroffContext forcefully stops the machine like Poweroff, bounded by the
+// given context.
+func (m *Machine) PoweroffContext(ctx context.Context) error {
+	if err := m.settle(ctx); err != nil {
+		return err
+	}
 	switch m.State {
-	case Poweroff, Aborted, Saved:
+	case Poweroff, Aborted, AbortedSaved, Saved:
 		return nil
 	}
-	return Manage().run("controlvm", m.Name, "poweroff")
+	if err := Manage().setOpts(withContext(ctx)).run("controlvm", m.Name, "poweroff"); err != nil {
+		return err
+	}
+	m.State = Poweroff
+	return nil
 }
 
 // Restart gracefully restarts the machine.
 func (m *Machine) Restart() error {
+	return m.RestartContext(context.Background())
+}
+
+// RestartContext gracefully restarts the machine like Restart, bounded by the
+// given context.
+func (m *Machine) RestartContext(ctx context.Context) error {
 	switch m.State {
 	case Paused, Saved:
-		if err := m.Start(); err != nil {
+		if err := m.StartContext(ctx); err != nil {
 			return err
 		}
 	}
-	if err := m.Stop(); err != nil {
+	if err := m.StopContext(ctx); err != nil {
 		return err
 	}
-	return m.Start()
+	return m.StartContext(ctx)
 }
 
 // Reset forcefully restarts the machine. State is lost and might corrupt the disk image.
@@ -206,32 +613,107 @@ func (m *Machine) Reset() error {
 
 // Delete deletes the machine and associated disk images.
 func (m *Machine) Delete() error {
+	return m.Unregister(true)
+}
+
+// Unregister powers off and unregisters the machine. Its files, including
+// the associated disk images, are deleted when deleteFiles is true.
+func (m *Machine) Unregister(deleteFiles bool) error {
 	if err := m.Poweroff(); err != nil {
 		return err
 	}
-	return Manage().run("unregistervm", m.Name, "--delete")
+	if deleteFiles {
+		return Manage().run("unregistervm", m.Name, "--delete")
+	}
+	return Manage().run("unregistervm", m.Name)
 }
 
-var mutex sync.Mutex
+// RegisterVM registers the machine described by the given .vbox settings file.
+func RegisterVM(vboxFile string) (*Machine, error) {
+	/* Read the machine UUID first, as 'registervm' does not report it. */
+	f, err := os.Open(vboxFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var cfg struct {
+		Machine struct {
+			UUID string `xml:"uuid,attr"`
+		}
+	}
+	if err := xml.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Machine.UUID == "" {
+		return nil, fmt.Errorf("could not find the machine UUID in %s", vboxFile)
+	}
 
-// GetMachine finds a machine by its name or UUID.
-func GetMachine(id string) (*Machine, error) {
-	/* There is a strage behavior where running multiple instances of
-	'VBoxManage showvminfo' on same VM simultaneously can return an error of
-	'object is not ready (E_ACCESSDENIED)', so we sequential the operation with a mutex.
-	Note if you are running multiple process of go-virtualbox or 'showvminfo'
-	in the command line side by side, this not gonna work. */
-	mutex.Lock()
-	stdout, stderr, err := Manage().runOutErr("showvminfo", id, "--machinereadable")
-	mutex.Unlock()
+	if err := Manage().run("registervm", vboxFile); err != nil {
+		return nil, err
+	}
+	return GetMachine(strings.Trim(cfg.Machine.UUID, "{}"))
+}
+
+// There is a strage behavior where running multiple instances of 'VBoxManage
+// showvminfo' on same VM simultaneously can return an error of 'object is not
+// ready (E_ACCESSDENIED)', so we sequential the operation with a mutex. Note if
+// you are running multiple process of go-virtualbox or 'showvminfo' in the command
+// line side by side, this not gonna work, and the command is retried instead (see
+// WithMaxRetries).
+//
+// A machine may be read by name or by UUID, so showVMInfo locks vmInfoMu for all
+// the machines. ListMachinesParallel only read-locks it, and reads each machine
+// by UUID, locking one of vmInfoStripes picked by that UUID instead.
+var (
+	vmInfoMu      sync.RWMutex
+	vmInfoStripes [16]sync.Mutex
+)
+
+// showVMInfo returns the machine readable information of a machine.
+func showVMInfo(id string) (string, error) {
+	vmInfoMu.Lock()
+	defer vmInfoMu.Unlock()
+	return runShowVMInfo(context.Background(), id)
+}
+
+// showVMInfoParallel is like showVMInfo, but may run along with the reads of
+// other machines. The machine must be given by UUID.
+func showVMInfoParallel(ctx context.Context, uuid string) (string, error) {
+	vmInfoMu.RLock()
+	defer vmInfoMu.RUnlock()
+	h := fnv.New32a()
+	h.Write([]byte(uuid))
+	stripe := &vmInfoStripes[h.Sum32()%uint32(len(vmInfoStripes))]
+	stripe.Lock()
+	defer stripe.Unlock()
+	return runShowVMInfo(ctx, uuid)
+}
+
+func runShowVMInfo(ctx context.Context, id string) (string, error) {
+	stdout, stderr, err := Manage().setOpts(withContext(ctx), retryReads()).runOutErr("showvminfo", id, "--machinereadable")
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		if reMachineNotFound.FindString(stderr) != "" {
-			return nil, ErrMachineNotExist
+			return "", ErrMachineNotExist
 		}
+		return "", err
+	}
+	return stdout, nil
+}
+
+// vmInfo reads the machine readable information of a machine into a map.
+func vmInfo(id string) (map[string]string, error) {
+	stdout, err := showVMInfo(id)
+	if err != nil {
 		return nil, err
 	}
+	return parseVMInfo(stdout)
+}
 
-	/* Read all VM info into a map */
+// parseVMInfo reads the output of 'showvminfo --machinereadable' into a map.
+func parseVMInfo(stdout string) (map[string]string, error) {
 	propMap := make(map[string]string)
 	s := bufio.NewScanner(strings.NewReader(stdout))
 	for s.Scan() {
@@ -249,33 +731,126 @@ func GetMachine(id string) (*Machine, error) {
 		}
 		propMap[key] = val
 	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return propMap, nil
+}
 
+// GetMachine finds a machine by its name or UUID.
+func GetMachine(id string) (*Machine, error) {
+	propMap, err := vmInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	return machineFromVMInfo(propMap)
+}
+
+// machineFromVMInfo returns the machine described by the machine readable
+// information read by vmInfo.
+func machineFromVMInfo(propMap map[string]string) (*Machine, error) {
 	/* Extract basic info */
 	m := New()
 	m.Name = propMap["name"]
-	m.Firmware = propMap["firmware"]
+	m.Firmware = Firmware(strings.ToLower(propMap["firmware"]))
 	m.UUID = propMap["UUID"]
 	m.State = MachineState(propMap["VMState"])
+	m.OSType = osTypeID(propMap["ostype"])
+	if groups := propMap["groups"]; groups != "" {
+		m.Groups = strings.Split(groups, ",")
+	}
+	m.ParavirtProvider = ParavirtProvider(propMap["paravirtprovider"])
+	m.Chipset = Chipset(propMap["chipset"])
+	m.TPMType = parseTPMType(propMap["tpm_type"])
+	m.TPMLocation = propMap["tpm_location"]
+	m.GraphicsController = GraphicsController(propMap["graphicscontroller"])
+	m.ClipboardMode = propMap["clipboard"]
+	m.DragAndDropMode = propMap["draganddrop"]
+	for _, f := range flagOptions {
+		val, ok := propMap[f.option]
+		if ok {
+			m.readFlags |= f.flag
+		}
+		if val == "on" {
+			m.Flag |= f.flag
+		}
+	}
 	n, err := strconv.ParseUint(propMap["memory"], 10, 32)
 	if err != nil {
 		return nil, err
 	}
 	m.Memory = uint(n)
+	if v, ok := propMap["GuestMemoryBalloon"]; ok {
+		n, err = strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		m.GuestMemoryBalloon = uint(n)
+	}
 	n, err = strconv.ParseUint(propMap["cpus"], 10, 32)
 	if err != nil {
 		return nil, err
 	}
 	m.CPUs = uint(n)
+	if v, ok := propMap["cpuexecutioncap"]; ok {
+		n, err = strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		m.CPUExecutionCap = uint(n)
+	}
 	n, err = strconv.ParseUint(propMap["vram"], 10, 32)
 	if err != nil {
 		return nil, err
 	}
 	m.VRAM = uint(n)
+	if v, ok := propMap["monitorcount"]; ok {
+		n, err = strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		m.MonitorCount = uint(n)
+	}
 	m.CfgFile = propMap["CfgFile"]
 	m.BaseFolder = filepath.Dir(m.CfgFile)
 
-	/* Extract NIC info */
+	/* Extract remote display info. The configured ports are in 'vrdeports',
+	while 'vrdeport' is the port in use by a running machine. */
+	m.VRDE.Enabled = propMap["vrde"] == "on"
+	m.VRDE.Port = propMap["vrdeports"]
+	if m.VRDE.Port == "" && propMap["vrdeport"] != "-1" {
+		m.VRDE.Port = propMap["vrdeport"]
+	}
+	m.VRDE.Address = propMap["vrdeaddress"]
+	m.VRDE.AuthType = propMap["vrdeauthtype"]
+
+	/* Extract audio info */
+	m.Audio.Driver = propMap["audio"]
+	m.Audio.Controller = propMap["audio_controller"]
+	m.Audio.In = propMap["audio_in"] == "on"
+	m.Audio.Out = propMap["audio_out"] == "on"
+
+	/* Extract recording info of the first screen */
+	m.Recording.Enabled = propMap["recording_enabled"] == "on"
+	m.Recording.File = propMap["rec_screen_dest_filename"]
+	m.Recording.VideoRes = propMap["rec_screen_video_res_xy"]
+	if v, ok := propMap["rec_screen_video_fps"]; ok {
+		n, err = strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		m.Recording.FPS = uint(n)
+	}
+
+	/* Extract boot order */
 	for i := 1; i <= 4; i++ {
+		if dev, ok := propMap[fmt.Sprintf("boot%d", i)]; ok && dev != "none" {
+			m.BootOrder = append(m.BootOrder, dev)
+		}
+	}
+
+	/* Extract NIC info */
+	for i := 1; i <= MaxNICs; i++ {
 		var nic NIC
 		nicType, ok := propMap[fmt.Sprintf("nic%d", i)]
 		if !ok || nicType == "none" {
@@ -290,17 +865,40 @@ func GetMachine(id string) (*Machine, error) {
 		if nic.MacAddr == "" {
 			return nil, fmt.Errorf("Could not find corresponding 'macaddress%d'", i)
 		}
-		if nic.Network == NICNetHostonly {
-			nic.HostInterface = propMap[fmt.Sprintf("hostonlyadapter%d", i)]
-		} else if nic.Network == NICNetBridged {
-			nic.HostInterface = propMap[fmt.Sprintf("bridgeadapter%d", i)]
+		if opt, ok := nicHostInterfaceOptions[nic.Network]; ok {
+			nic.HostInterface = propMap[fmt.Sprintf("%s%d", opt.key, i)]
 		}
+		nic.PromiscMode = propMap[fmt.Sprintf("nicpromisc%d", i)]
 		m.NICs = append(m.NICs, nic)
 	}
 
-	if err := s.Err(); err != nil {
-		return nil, err
+	/* Extract serial ports */
+	for i := 1; i <= MaxSerialPorts; i++ {
+		uart, ok := propMap[fmt.Sprintf("uart%d", i)]
+		if !ok {
+			break
+		}
+		port, err := parseSerialPort(uart, propMap[fmt.Sprintf("uartmode%d", i)])
+		if err != nil {
+			return nil, err
+		}
+		m.SerialPorts = append(m.SerialPorts, port)
+	}
+
+	/* Extract USB filters */
+	for i := 1; ; i++ {
+		name, ok := propMap[fmt.Sprintf("USBFilterName%d", i)]
+		if !ok {
+			break
+		}
+		m.USBFilters = append(m.USBFilters, USBFilter{
+			Name:      name,
+			VendorID:  propMap[fmt.Sprintf("USBFilterVendorId%d", i)],
+			ProductID: propMap[fmt.Sprintf("USBFilterProductId%d", i)],
+			Remote:    propMap[fmt.Sprintf("USBFilterRemote%d", i)] == "yes",
+		})
 	}
+
 	return m, nil
 }
 
@@ -334,68 +932,292 @@ func ListMachines() ([]*Machine, error) {
 	return ms, nil
 }
 
-// CreateMachine creates a new machine. If basefolder is empty, use default.
-func CreateMachine(name, basefolder string) (*Machine, error) {
-	if name == "" {
-		return nil, fmt.Errorf("machine name is empty")
+// MachineRef identifies a registered machine.
+type MachineRef struct {
+	Name string
+	UUID string
+}
+
+// ListMachineNames lists the names and UUIDs of the registered machines,
+// without reading their whole information as ListMachines does.
+func ListMachineNames(ctx context.Context) ([]MachineRef, error) {
+	out, err := Manage().setOpts(withContext(ctx)).runOut("list", "vms")
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	refs := []MachineRef{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		if res := reVMNameUUID.FindStringSubmatch(s.Text()); res != nil {
+			refs = append(refs, MachineRef{Name: res[1], UUID: res[2]})
+		}
 	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
 
-	// Check if a machine with the given name already exists.
+// ListMachinesParallel lists all registered machines like ListMachines, but
+// reads up to maxConcurrency machines at the same time. The information of a
+// given machine is still never read concurrently. Cancelling ctx stops the
+// reads in progress.
+func ListMachinesParallel(ctx context.Context, maxConcurrency int) ([]*Machine, error) {
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	refs, err := ListMachineNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	machines := make([]*Machine, len(refs))
+	errs := make([]error, len(refs))
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, uuid string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			machines[i], errs[i] = getMachineParallel(ctx, uuid)
+			if errs[i] != nil && errs[i] != ErrMachineNotExist {
+				cancel()
+			}
+		}(i, ref.UUID)
+	}
+	wg.Wait()
+
+	ms := []*Machine{}
+	for i, m := range machines {
+		// Sometimes a VM is listed but not available, so we need to handle this.
+		if errs[i] == ErrMachineNotExist {
+			continue
+		} else if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if m != nil {
+			ms = append(ms, m)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// getMachineParallel finds a machine by its UUID like GetMachine, along with
+// other machines.
+func getMachineParallel(ctx context.Context, uuid string) (*Machine, error) {
+	stdout, err := showVMInfoParallel(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	propMap, err := parseVMInfo(stdout)
+	if err != nil {
+		return nil, err
+	}
+	return machineFromVMInfo(propMap)
+}
+
+// ListMachinesByGroup lists the registered machines in the given group, such
+// as /Production, or in one of its subgroups.
+func ListMachinesByGroup(group string) ([]*Machine, error) {
 	ms, err := ListMachines()
 	if err != nil {
 		return nil, err
 	}
+	group = strings.TrimSuffix(group, "/")
+	filtered := []*Machine{}
 	for _, m := range ms {
-		if m.Name == name {
-			return nil, ErrMachineExist
+		for _, g := range m.Groups {
+			if g == group || strings.HasPrefix(g, group+"/") {
+				filtered = append(filtered, m)
+				break
+			}
 		}
 	}
+	return filtered, nil
+}
+
+// CreateMachine creates a new machine. If basefolder is empty, use default.
+// It returns ErrMachineExist when a machine with that name already exists.
+func CreateMachine(name, basefolder string) (*Machine, error) {
+	if name == "" {
+		return nil, fmt.Errorf("machine name is empty")
+	}
 
 	// Create and register the machine.
 	args := []string{"createvm", "--name", name, "--register"}
 	if basefolder != "" {
 		args = append(args, "--basefolder", basefolder)
 	}
-	if err = Manage().run(args...); err != nil {
-		return nil, err
+	return createMachine(name, args)
+}
+
+// CreateOptions holds the settings of a new machine.
+type CreateOptions struct {
+	Name       string
+	BaseFolder string   // VirtualBox default when empty
+	OSType     string   // guest OS type ID, see ListOSTypes
+	Groups     []string // slash-delimited paths, e.g. /Production/web
+	UUID       string   // generated when empty
+	Default    bool     // apply the default hardware settings of the OSType
+}
+
+// CreateMachineOpts creates a new machine with the given settings, like
+// CreateMachine.
+func CreateMachineOpts(opts CreateOptions) (*Machine, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("machine name is empty")
+	}
+
+	args := []string{"createvm", "--name", opts.Name, "--register"}
+	if opts.BaseFolder != "" {
+		args = append(args, "--basefolder", opts.BaseFolder)
+	}
+	if opts.OSType != "" {
+		args = append(args, "--ostype", opts.OSType)
+	}
+	if len(opts.Groups) > 0 {
+		args = append(args, "--groups", strings.Join(opts.Groups, ","))
+	}
+	if opts.UUID != "" {
+		args = append(args, "--uuid", opts.UUID)
 	}
+	if opts.Default {
+		args = append(args, "--default")
+	}
+	return createMachine(opts.Name, args)
+}
 
-	m, err := GetMachine(name)
+// createMachine runs the given 'createvm' command, and returns the new
+// machine, read by the UUID it printed with the defaults VirtualBox chose.
+func createMachine(name string, args []string) (*Machine, error) {
+	stdout, stderr, err := Manage().runOutErr(args...)
 	if err != nil {
+		if reMachineExist.MatchString(stderr) {
+			return nil, ErrMachineExist
+		}
 		return nil, err
 	}
 
+	id := name
+	if res := reUUID.FindStringSubmatch(stdout); res != nil {
+		id = res[1]
+	}
+	m, err := GetMachine(id)
+	if err != nil {
+		return nil, err
+	}
+	if res := reSettingsFile.FindStringSubmatch(stdout); res != nil && m.CfgFile == "" {
+		m.CfgFile = res[1]
+		m.BaseFolder = filepath.Dir(m.CfgFile)
+	}
 	return m, nil
 }
 
+// maxVRAM is the maximum video memory (in MB) of a machine.
+const maxVRAM = 256
+
+// vramPerMonitor is the video memory (in MB) needed by a 1920x1200 screen at
+// 32 bits per pixel.
+const vramPerMonitor = 9
+
+// validate checks the settings of the machine before they are changed, as
+// VirtualBox may otherwise adjust them silently.
+func (m *Machine) validate() error {
+	if m.VRAM > maxVRAM {
+		return fmt.Errorf("video memory %d MB is more than the maximum %d MB", m.VRAM, maxVRAM)
+	}
+	if m.VRAM != 0 && m.MonitorCount > 1 && m.VRAM < m.MonitorCount*vramPerMonitor {
+		return fmt.Errorf("%d MB of video memory is not enough for %d monitors, at least %d MB are needed",
+			m.VRAM, m.MonitorCount, m.MonitorCount*vramPerMonitor)
+	}
+	if StrictFlags {
+		return m.Flag.Validate()
+	}
+	return nil
+}
+
 // Modify changes the settings of the machine.
 func (m *Machine) Modify() error {
-	args := []string{"modifyvm", m.Name,
-		"--firmware", m.Firmware,
-		"--bioslogofadein", "off",
-		"--bioslogofadeout", "off",
-		"--bioslogodisplaytime", "0",
-		"--biosbootmenu", "disabled",
-
-		"--ostype", m.OSType,
+	if err := m.validate(); err != nil {
+		return err
+	}
+	firmware := m.Firmware
+	if firmware == "" {
+		firmware = FirmwareBIOS
+	}
+	args := []string{"modifyvm", m.Name, "--firmware", string(firmware)}
+	if firmware == FirmwareBIOS {
+		args = append(args,
+			"--bioslogofadein", "off",
+			"--bioslogofadeout", "off",
+			"--bioslogodisplaytime", "0",
+			"--biosbootmenu", "disabled")
+	}
+
+	if m.OSType != "" {
+		args = append(args, "--ostype", m.OSType)
+	}
+	args = append(args,
 		"--cpus", fmt.Sprintf("%d", m.CPUs),
 		"--memory", fmt.Sprintf("%d", m.Memory),
-		"--vram", fmt.Sprintf("%d", m.VRAM),
-
-		"--acpi", m.Flag.Get(ACPI),
-		"--ioapic", m.Flag.Get(IOAPIC),
-		"--rtcuseutc", m.Flag.Get(RTCUSEUTC),
-		"--cpuhotplug", m.Flag.Get(CPUHOTPLUG),
-		"--pae", m.Flag.Get(PAE),
-		"--longmode", m.Flag.Get(LONGMODE),
-		"--hpet", m.Flag.Get(HPET),
-		"--hwvirtex", m.Flag.Get(HWVIRTEX),
-		"--triplefaultreset", m.Flag.Get(TRIPLEFAULTRESET),
-		"--nestedpaging", m.Flag.Get(NESTEDPAGING),
-		"--largepages", m.Flag.Get(LARGEPAGES),
-		"--vtxvpid", m.Flag.Get(VTXVPID),
-		"--vtxux", m.Flag.Get(VTXUX),
-		"--accelerate3d", m.Flag.Get(ACCELERATE3D),
+	)
+	if m.VRAM != 0 {
+		args = append(args, "--vram", fmt.Sprintf("%d", m.VRAM))
+	}
+	for _, f := range flagOptions {
+		if f.recent && (m.Flag|m.readFlags)&f.flag == 0 {
+			continue
+		}
+		args = append(args, "--"+f.option, m.Flag.Get(f.flag))
+	}
+
+	if len(m.Groups) > 0 {
+		args = append(args, "--groups", strings.Join(m.Groups, ","))
+	}
+	if m.ParavirtProvider != "" {
+		args = append(args, "--paravirtprovider", string(m.ParavirtProvider))
+	}
+	if m.Chipset != "" {
+		args = append(args, "--chipset", string(m.Chipset))
+	}
+	if m.MonitorCount != 0 {
+		args = append(args, "--monitorcount", fmt.Sprintf("%d", m.MonitorCount))
+	}
+	if m.TPMType != "" || m.TPMLocation != "" {
+		if err := requireVersion("TPM", vbox7); err != nil {
+			return err
+		}
+		if m.TPMType != "" {
+			args = append(args, "--tpm-type", string(m.TPMType))
+		}
+		if m.TPMLocation != "" {
+			args = append(args, "--tpm-location", m.TPMLocation)
+		}
+	}
+	if m.GraphicsController != "" {
+		args = append(args, "--graphicscontroller", string(m.GraphicsController))
+	}
+	if m.CPUExecutionCap != 0 {
+		if err := checkCPUExecutionCap(m.CPUExecutionCap); err != nil {
+			return err
+		}
+		args = append(args, "--cpuexecutioncap", fmt.Sprintf("%d", m.CPUExecutionCap))
 	}
 
 	for i, dev := range m.BootOrder {
@@ -406,16 +1228,14 @@ func (m *Machine) Modify() error {
 	}
 
 	for i, nic := range m.NICs {
-		n := i + 1
-		args = append(args,
-			fmt.Sprintf("--nic%d", n), string(nic.Network),
-			fmt.Sprintf("--nictype%d", n), string(nic.Hardware),
-			fmt.Sprintf("--cableconnected%d", n), "on")
-		if nic.Network == NICNetHostonly {
-			args = append(args, fmt.Sprintf("--hostonlyadapter%d", n), nic.HostInterface)
-		} else if nic.Network == NICNetBridged {
-			args = append(args, fmt.Sprintf("--bridgeadapter%d", n), nic.HostInterface)
+		if i >= MaxNICs {
+			break // Only MaxNICs slots `--nic{1..8}`. Ignore the rest.
 		}
+		nicargs, err := nicArgs(i+1, nic)
+		if err != nil {
+			return err
+		}
+		args = append(args, nicargs...)
 	}
 
 	if err := Manage().run(args...); err != nil {
@@ -424,12 +1244,144 @@ func (m *Machine) Modify() error {
 	return m.Refresh()
 }
 
+// SetBootOrder sets the boot order of the machine, with at most four devices
+// in {none|floppy|dvd|disk|net}. The remaining slots are set to none.
+func (m *Machine) SetBootOrder(order ...string) error {
+	if len(order) > 4 {
+		order = order[:4] // Only four slots `--boot{1,2,3,4}`. Ignore the rest.
+	}
+	args := []string{"modifyvm", m.Name}
+	for i := 0; i < 4; i++ {
+		dev := "none"
+		if i < len(order) {
+			dev = order[i]
+		}
+		switch dev {
+		case "none", "floppy", "dvd", "disk", "net":
+		default:
+			return fmt.Errorf("invalid boot device %q", dev)
+		}
+		args = append(args, fmt.Sprintf("--boot%d", i+1), dev)
+	}
+	if err := Manage().run(args...); err != nil {
+		return err
+	}
+	m.BootOrder = append(m.BootOrder[:0], order...)
+	return nil
+}
+
+// SetCPUExecutionCap limits how much of a host CPU each virtual CPU of the
+// running machine can use, as a percentage in 1-100.
+func (m *Machine) SetCPUExecutionCap(pct uint) error {
+	if err := checkCPUExecutionCap(pct); err != nil {
+		return err
+	}
+	if err := Manage().run("controlvm", m.Name, "cpuexecutioncap", fmt.Sprintf("%d", pct)); err != nil {
+		return err
+	}
+	m.CPUExecutionCap = pct
+	return nil
+}
+
+func checkCPUExecutionCap(pct uint) error {
+	if pct < 1 || pct > 100 {
+		return fmt.Errorf("CPU execution cap %d is out of the 1-100 range", pct)
+	}
+	return nil
+}
+
+// Rename renames the machine, which must be powered off.
+func (m *Machine) Rename(newName string) error {
+	if newName == "" {
+		return fmt.Errorf("machine name is empty")
+	}
+	if err := m.checkState("rename", Poweroff, Aborted); err != nil {
+		return err
+	}
+
+	// Check if a machine with the given name already exists.
+	ms, err := ListMachines()
+	if err != nil {
+		return err
+	}
+	for _, mm := range ms {
+		if mm.Name == newName {
+			return ErrMachineExist
+		}
+	}
+
+	if err := Manage().run("modifyvm", m.Name, "--name", newName); err != nil {
+		return err
+	}
+	m.Name = newName
+	return nil
+}
+
+// Move moves the files of the machine to targetFolder, keeping it registered,
+// and refreshes the machine. The machine must be powered off. It requires
+// VirtualBox 6 or later.
+func (m *Machine) Move(targetFolder string) error {
+	if targetFolder == "" {
+		return fmt.Errorf("target folder is empty")
+	}
+	if err := m.checkState("move", Poweroff, Aborted); err != nil {
+		return err
+	}
+	if err := Manage().run("movevm", m.Name, "--type", "basic", "--folder", targetFolder); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
 // AddNATPF adds a NAT port forarding rule to the n-th NIC with the given name.
 func (m *Machine) AddNATPF(n int, name string, rule PFRule) error {
 	return Manage().run("controlvm", m.Name, fmt.Sprintf("natpf%d", n),
 		fmt.Sprintf("%s,%s", name, rule.Format()))
 }
 
+// ListNATPF lists the NAT port forwarding rules of the n-th NIC.
+func (m *Machine) ListNATPF(n int) ([]NamedPFRule, error) {
+	stdout, err := showVMInfo(m.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	/* The rules of all NICs share the 'Forwarding(i)' keys, but follow the
+	'nic<n>' line of their NIC. */
+	nicKey := fmt.Sprintf("nic%d", n)
+	rules := []NamedPFRule{}
+	inNIC := false
+	s := bufio.NewScanner(strings.NewReader(stdout))
+	for s.Scan() {
+		res := reVMInfoLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		key := res[1]
+		if key == "" {
+			key = res[2]
+		}
+		val := res[3]
+		if val == "" {
+			val = res[4]
+		}
+		switch {
+		case reNICKey.MatchString(key):
+			inNIC = key == nicKey
+		case inNIC && reForwardingKey.MatchString(key):
+			name, rule, err := ParsePFRule(val)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, NamedPFRule{Name: name, Rule: rule})
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
 // DelNATPF deletes the NAT port forwarding rule with the given name from the n-th NIC.
 func (m *Machine) DelNATPF(n int, name string) error {
 	return Manage().run("controlvm", m.Name, fmt.Sprintf("natpf%d", n), "delete", name)
@@ -437,16 +1389,42 @@ func (m *Machine) DelNATPF(n int, name string) error {
 
 // SetNIC set the n-th NIC.
 func (m *Machine) SetNIC(n int, nic NIC) error {
-	args := []string{"modifyvm", m.Name,
-		fmt.Sprintf("--nic%d", n), string(nic.Network),
-		fmt.Sprintf("--nictype%d", n), string(nic.Hardware),
-		fmt.Sprintf("--cableconnected%d", n), "on",
+	nicargs, err := nicArgs(n, nic)
+	if err != nil {
+		return err
 	}
+	return Manage().run(append([]string{"modifyvm", m.Name}, nicargs...)...)
+}
+
+// SetCableConnected plugs, or pulls, the virtual network cable of the n-th
+// NIC. The cable of a running machine is changed live.
+func (m *Machine) SetCableConnected(n int, connected bool) error {
+	switch m.State {
+	case Running, Paused:
+		return Manage().run("controlvm", m.Name, fmt.Sprintf("setlinkstate%d", n), bool2string(connected))
+	}
+	return Manage().run("modifyvm", m.Name, fmt.Sprintf("--cableconnected%d", n), bool2string(connected))
+}
 
-	if nic.Network == NICNetHostonly {
-		args = append(args, fmt.Sprintf("--hostonlyadapter%d", n), nic.HostInterface)
-	} else if nic.Network == NICNetBridged {
-		args = append(args, fmt.Sprintf("--bridgeadapter%d", n), nic.HostInterface)
+// SetNICTrace starts, or stops, capturing the traffic of the n-th NIC to the
+// given pcap file, which is unchanged when empty. The trace of a running
+// machine is changed live.
+func (m *Machine) SetNICTrace(n int, enabled bool, pcapPath string) error {
+	if n < 1 || n > MaxNICs {
+		return fmt.Errorf("invalid NIC number %d", n)
+	}
+	switch m.State {
+	case Running, Paused:
+		if pcapPath != "" {
+			if err := Manage().run("controlvm", m.Name, fmt.Sprintf("nictracefile%d", n), pcapPath); err != nil {
+				return err
+			}
+		}
+		return Manage().run("controlvm", m.Name, fmt.Sprintf("nictrace%d", n), bool2string(enabled))
+	}
+	args := []string{"modifyvm", m.Name, fmt.Sprintf("--nictrace%d", n), bool2string(enabled)}
+	if pcapPath != "" {
+		args = append(args, fmt.Sprintf("--nictracefile%d", n), pcapPath)
 	}
 	return Manage().run(args...)
 }
@@ -475,44 +1453,197 @@ func (m *Machine) DelStorageCtl(name string) error {
 
 // AttachStorage attaches a storage medium to the named storage controller.
 func (m *Machine) AttachStorage(ctlName string, medium StorageMedium) error {
-	return Manage().run("storageattach", m.Name, "--storagectl", ctlName,
+	return m.AttachStorageContext(context.Background(), ctlName, medium)
+}
+
+// AttachStorageContext attaches a storage medium to the named storage
+// controller like AttachStorage, bounded by the given context.
+func (m *Machine) AttachStorageContext(ctx context.Context, ctlName string, medium StorageMedium) error {
+	args := []string{"storageattach", m.Name, "--storagectl", ctlName,
 		"--port", fmt.Sprintf("%d", medium.Port),
 		"--device", fmt.Sprintf("%d", medium.Device),
 		"--type", string(medium.DriveType),
 		"--medium", medium.Medium,
+	}
+	if medium.NonRotational {
+		args = append(args, "--nonrotational", "on")
+	}
+	if medium.Hotpluggable {
+		args = append(args, "--hotpluggable", "on")
+	}
+	if medium.BandwidthGroup != "" {
+		args = append(args, "--bandwidthgroup", medium.BandwidthGroup)
+	}
+	return runContext(ctx, args...)
+}
+
+// DetachStorage removes the medium attached to the given port and device of
+// the named storage controller.
+func (m *Machine) DetachStorage(ctlName string, port, device int) error {
+	_, stderr, err := Manage().runOutErr("storageattach", m.Name, "--storagectl", ctlName,
+		"--port", fmt.Sprintf("%d", port),
+		"--device", fmt.Sprintf("%d", device),
+		"--medium", "none",
 	)
+	if err != nil {
+		if reStorageCtlNotFound.MatchString(stderr) {
+			return ErrStorageCtlNotExist
+		}
+		return err
+	}
+	return nil
 }
 
 // SetExtraData attaches custom string to the VM.
 func (m *Machine) SetExtraData(key, val string) error {
-	return Manage().run("setextradata", m.Name, key, val)
+	return m.SetExtraDataContext(context.Background(), key, val)
+}
+
+// SetExtraDataContext attaches custom string to the VM like SetExtraData,
+// bounded by the given context.
+func (m *Machine) SetExtraDataContext(ctx context.Context, key, val string) error {
+	return runContext(ctx, "setextradata", m.Name, key, val)
 }
 
 // GetExtraData retrieves custom string from the VM.
 func (m *Machine) GetExtraData(key string) (*string, error) {
-	value, err := Manage().runOut("getextradata", m.Name, key)
-	if err != nil {
-		return nil, err
-	}
-	value = strings.TrimSpace(value)
-	/* 'getextradata get' returns 0 even when the key is not found,
-	so we need to check stdout for this case */
-	if strings.HasPrefix(value, "No value set") {
-		return nil, nil
-	}
-	trimmed := strings.TrimPrefix(value, "Value: ")
-	return &trimmed, nil
+	return m.GetExtraDataContext(context.Background(), key)
+}
+
+// GetExtraDataContext retrieves custom string from the VM like GetExtraData,
+// bounded by the given context.
+func (m *Machine) GetExtraDataContext(ctx context.Context, key string) (*string, error) {
+	return getExtraData(ctx, m.Name, key)
 }
 
-// DeleteExtraData removes custom string from the VM.
+// DeleteExtraData removes custom string from the VM. VBoxManage deletes the
+// key when 'setextradata' is given no value, GetExtraData then returns nil.
 func (m *Machine) DeleteExtraData(key string) error {
-	return Manage().run("setextradata", m.Name, key)
+	return m.DeleteExtraDataContext(context.Background(), key)
+}
+
+// DeleteExtraDataContext removes custom string from the VM like
+// DeleteExtraData, bounded by the given context.
+func (m *Machine) DeleteExtraDataContext(ctx context.Context, key string) error {
+	return runContext(ctx, "setextradata", m.Name, key)
 }
 
 // CloneMachine clones the given machine name into a new one.
 func CloneMachine(baseImageName string, newImageName string, register bool) error {
+	return CloneMachineContext(context.Background(), baseImageName, newImageName, register)
+}
+
+// CloneMachineContext clones the given machine name into a new one like
+// CloneMachine, bounded by the given context.
+func CloneMachineContext(ctx context.Context, baseImageName string, newImageName string, register bool) error {
 	if register {
-		return Manage().run("clonevm", baseImageName, "--name", newImageName, "--register")
+		return runContext(ctx, "clonevm", baseImageName, "--name", newImageName, "--register")
+	}
+	return runContext(ctx, "clonevm", baseImageName, "--name", newImageName)
+}
+
+// CloneMode selects what is cloned from the snapshot tree of a machine.
+type CloneMode string
+
+const (
+	// CloneModeMachine is a CloneMode value.
+	CloneModeMachine = CloneMode("machine")
+	// CloneModeMachineAndChildren is a CloneMode value.
+	CloneModeMachineAndChildren = CloneMode("machineandchildren")
+	// CloneModeAll is a CloneMode value.
+	CloneModeAll = CloneMode("all")
+)
+
+// CloneOptions holds the settings of a machine clone.
+type CloneOptions struct {
+	Name       string    // name of the new machine
+	Snapshot   string    // snapshot to clone, the current state when empty
+	Mode       CloneMode // VirtualBox default when empty
+	Linked     bool      // create differencing disks instead of copies, requires a Snapshot
+	BaseFolder string    // where to create the new machine, VirtualBox default when empty
+}
+
+// CloneMachineOpts clones the machine src, given by name or UUID, into a new
+// registered machine, and returns it.
+func CloneMachineOpts(src string, opts CloneOptions) (*Machine, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("clone name is empty")
+	}
+	if opts.Linked && opts.Snapshot == "" {
+		return nil, fmt.Errorf("linked clone of machine %q requires a snapshot", src)
+	}
+
+	args := []string{"clonevm", src, "--name", opts.Name, "--register"}
+	if opts.Snapshot != "" {
+		args = append(args, "--snapshot", opts.Snapshot)
+	}
+	if opts.Mode != "" {
+		args = append(args, "--mode", string(opts.Mode))
 	}
-	return Manage().run("clonevm", baseImageName, "--name", newImageName)
+	if opts.Linked {
+		args = append(args, "--options", "link")
+	}
+	if opts.BaseFolder != "" {
+		args = append(args, "--basefolder", opts.BaseFolder)
+	}
+	if err := Manage().run(args...); err != nil {
+		return nil, err
+	}
+	return GetMachine(opts.Name)
+}
+
+// QuickCloneOptions holds the settings of QuickClone.
+type QuickCloneOptions struct {
+	Snapshot   string // snapshot of the source machine to link the clone to
+	CPUs       uint   // unchanged when 0
+	Memory     uint   // main memory (in MB), unchanged when 0
+	BaseFolder string // where to create the new machine, VirtualBox default when empty
+}
+
+// QuickClone creates a linked clone of the machine src, given by name or UUID,
+// from the given snapshot, changes its CPUs and memory, and starts it
+// headless. The clone is deleted when it cannot be set up or started.
+func QuickClone(src, newName string, opts QuickCloneOptions) (*Machine, error) {
+	m, err := CloneMachineOpts(src, CloneOptions{
+		Name:       newName,
+		Snapshot:   opts.Snapshot,
+		Linked:     true,
+		BaseFolder: opts.BaseFolder,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := m.quickSetup(opts); err != nil {
+		if derr := m.Delete(); derr != nil {
+			Debug("QuickClone(): deleting machine %s: %v", m.Name, derr)
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Machine) quickSetup(opts QuickCloneOptions) error {
+	if opts.CPUs > 0 || opts.Memory > 0 {
+		args := []string{"modifyvm", m.Name}
+		if opts.CPUs > 0 {
+			args = append(args, "--cpus", fmt.Sprintf("%d", opts.CPUs))
+		}
+		if opts.Memory > 0 {
+			args = append(args, "--memory", fmt.Sprintf("%d", opts.Memory))
+		}
+		if err := Manage().run(args...); err != nil {
+			return err
+		}
+		if opts.CPUs > 0 {
+			m.CPUs = opts.CPUs
+		}
+		if opts.Memory > 0 {
+			m.Memory = opts.Memory
+		}
+	}
+	if err := m.Start(); err != nil {
+		return err
+	}
+	m.State = Running
+	return nil
 }
@@ -1,7 +1,12 @@
 package virtualbox
 
 import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 )
@@ -28,3 +33,1094 @@ func TestMachine(t *testing.T) {
 
 	Teardown()
 }
+
+func TestGetMachine(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+	}
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", m)
+	if m.OSType == "" {
+		t.Fatal("OSType was not read")
+	}
+	if ManageMock != nil {
+		if m.OSType != "Ubuntu_64" {
+			t.Fatalf("unexpected OSType %q", m.OSType)
+		}
+		if m.Firmware != FirmwareBIOS {
+			t.Fatalf("unexpected Firmware %q", m.Firmware)
+		}
+		if m.ParavirtProvider != ParavirtDefault {
+			t.Fatalf("unexpected ParavirtProvider %q", m.ParavirtProvider)
+		}
+		if m.Chipset != ChipsetPIIX3 {
+			t.Fatalf("unexpected Chipset %q", m.Chipset)
+		}
+	}
+
+	Teardown()
+}
+
+func TestGetMachineNICs(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+	}
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", m.NICs)
+	if ManageMock != nil {
+		if len(m.NICs) != 6 {
+			t.Fatalf("expected 6 NICs, got %d", len(m.NICs))
+		}
+		if nic := m.NICs[3]; nic.Network != NICNetInternal || nic.HostInterface != "backend" {
+			t.Fatalf("unexpected internal network NIC %+v", nic)
+		}
+		if nic := m.NICs[1]; nic.PromiscMode != "allow-vms" {
+			t.Fatalf("unexpected promiscuous mode in %+v", nic)
+		}
+	}
+
+	Teardown()
+}
+
+func TestStopContext(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().run("controlvm", VM, "acpipowerbutton").Return(nil).Times(1)
+	}
+	m := &Machine{Name: VM, State: Running}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.StopContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	Teardown()
+}
+
+func TestSleep(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("sleeping would suspend the guest of TEST_VM")
+	}
+	ManageMock.EXPECT().run("controlvm", VM, "acpisleepbutton").Return(nil).Times(1)
+	m := &Machine{Name: VM, State: Running}
+	if err := m.Sleep(); err != nil {
+		t.Fatal(err)
+	}
+	m.State = Paused
+	if err := m.Sleep(); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	Setup(t)
+
+	defer func(timeout, interval time.Duration) {
+		StopTimeout, stopPollInterval = timeout, interval
+	}(StopTimeout, stopPollInterval)
+	StopTimeout, stopPollInterval = 50*time.Millisecond, 10*time.Millisecond
+
+	// The guest ignores the ACPI power button and keeps running.
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().run("controlvm", VM, "acpipowerbutton").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "appliance", "--machinereadable").Return(vmInfoOut, "", nil).AnyTimes(),
+		)
+		ManageMock.EXPECT().run("controlvm", "appliance", "poweroff").Return(nil).Times(1)
+	}
+	m := &Machine{Name: VM, State: Running}
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	Teardown()
+}
+
+func TestRestartStopTimeout(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	defer func(timeout, interval time.Duration) {
+		StopTimeout, stopPollInterval = timeout, interval
+	}(StopTimeout, stopPollInterval)
+	StopTimeout, stopPollInterval = 50*time.Millisecond, 10*time.Millisecond
+
+	// The guest ignores the ACPI power button, so it is powered off before
+	// being started again.
+	if ManageMock == nil {
+		t.Skip("restarting would change the state of TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().run("controlvm", VM, "acpipowerbutton").Return(nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "appliance", "--machinereadable").Return(vmInfoOut, "", nil).AnyTimes(),
+	)
+	gomock.InOrder(
+		ManageMock.EXPECT().run("controlvm", "appliance", "poweroff").Return(nil).Times(1),
+		ManageMock.EXPECT().runOutErr("startvm", "appliance", "--type", "headless").Return("", "", nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Running}
+	if err := m.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if m.State != Running {
+		t.Fatalf("unexpected state %s", m.State)
+	}
+}
+
+func TestStartInvalidState(t *testing.T) {
+	m := &Machine{Name: VM, State: MachineState("stuck")}
+	if err := m.Start(); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestMachineStateTransitional(t *testing.T) {
+	for _, s := range []MachineState{Starting, Stopping, Saving, Restoring} {
+		if !s.IsTransitional() {
+			t.Errorf("%s should be transitional", s)
+		}
+	}
+	for _, s := range []MachineState{Poweroff, Running, Paused, Saved, Aborted} {
+		if s.IsTransitional() {
+			t.Errorf("%s should not be transitional", s)
+		}
+	}
+	if !Starting.IsRunning() || !Running.IsRunning() || Paused.IsRunning() || Stopping.IsRunning() {
+		t.Error("unexpected IsRunning results")
+	}
+}
+
+func TestStartTransitional(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	defer func(interval time.Duration) { stopPollInterval = interval }(stopPollInterval)
+	stopPollInterval = 10 * time.Millisecond
+
+	// The machine is still saving its state when started.
+	if ManageMock == nil {
+		t.Skip("starting would change the state of TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("startvm", "go-virtualbox", "--type", "headless").Return("", "", nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Saving}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.State = Running
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartAbortedSaved(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("starting would change the state of TEST_VM")
+	}
+	ManageMock.EXPECT().runOutErr("startvm", VM, "--type", "headless").Return("", "", nil).Times(1)
+	m := &Machine{Name: VM, State: AbortedSaved}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if m.State != Running {
+		t.Fatalf("unexpected state %s", m.State)
+	}
+	// A crashed machine is already stopped.
+	m.State = AbortedSaved
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForState(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("waiting for a state change of TEST_VM would hang")
+	}
+	runningOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	savedOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(runningOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "appliance", "--machinereadable").Return(runningOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "appliance", "--machinereadable").Return(savedOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(savedOut, "", nil).AnyTimes(),
+	)
+	m := &Machine{Name: VM}
+	if err := m.WaitForState(context.Background(), Saved, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if m.State != Saved {
+		t.Fatalf("unexpected state %s", m.State)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.WaitForState(ctx, Running, time.Millisecond); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("waiting for a state change of TEST_VM would hang")
+	}
+	defer func(interval time.Duration) { WatchInterval = interval }(WatchInterval)
+	WatchInterval = time.Millisecond
+
+	runningOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	savedOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(runningOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "appliance", "--machinereadable").Return(runningOut, "", nil).Times(2),
+		ManageMock.EXPECT().runOutErr("showvminfo", "appliance", "--machinereadable").Return(savedOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(savedOut, "", nil).AnyTimes(),
+	)
+	m := &Machine{Name: VM}
+	ctx, cancel := context.WithCancel(context.Background())
+	states, err := m.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := <-states; state != Saved {
+		t.Fatalf("unexpected state %s", state)
+	}
+	cancel()
+	for state := range states {
+		t.Fatalf("unexpected state %s after cancel", state)
+	}
+	if m.State != "" {
+		t.Fatalf("machine was modified: %+v", m)
+	}
+}
+
+func TestRename(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("renaming would break the tests running against TEST_VM")
+	}
+	listVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOut("list", "vms").Return(listVmsOut, nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", VM, "--name", "renamed").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Poweroff}
+	if err := m.Rename("renamed"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "renamed" {
+		t.Fatalf("machine name was not updated: %q", m.Name)
+	}
+
+	m.State = Running
+	if err := m.Rename("other"); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestMove(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("moving would break the tests running against TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().run("movevm", VM, "--type", "basic", "--folder", "/mnt/big/vms").Return(nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Poweroff}
+	if err := m.Move("/mnt/big/vms"); err != nil {
+		t.Fatal(err)
+	}
+	if m.CfgFile == "" || m.BaseFolder == "" {
+		t.Fatalf("machine was not refreshed: %+v", m)
+	}
+
+	m.State = Running
+	if err := m.Move("/mnt/big/vms"); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestRegisterVM(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("registering would need a .vbox file of TEST_VM")
+	}
+	vboxFile := "testdata/go-virtualbox.vbox"
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().run("unregistervm", "go-virtualbox").Return(nil).Times(1),
+		ManageMock.EXPECT().run("registervm", vboxFile).Return(nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "37f5d336-bf07-48dd-947c-37e6a56420a7", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+	)
+	m := &Machine{Name: "go-virtualbox", State: Poweroff}
+	if err := m.Unregister(false); err != nil {
+		t.Fatal(err)
+	}
+	m, err := RegisterVM(vboxFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "go-virtualbox" {
+		t.Fatalf("unexpected machine %+v", m)
+	}
+}
+
+func TestSetCPUExecutionCap(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().run("controlvm", VM, "cpuexecutioncap", "50").Return(nil).Times(1)
+	}
+	m := &Machine{Name: VM, State: Running}
+	if ManageMock != nil {
+		if err := m.SetCPUExecutionCap(50); err != nil {
+			t.Fatal(err)
+		}
+		if m.CPUExecutionCap != 50 {
+			t.Fatalf("unexpected CPUExecutionCap %d", m.CPUExecutionCap)
+		}
+	}
+	for _, pct := range []uint{0, 101} {
+		if err := m.SetCPUExecutionCap(pct); err == nil {
+			t.Fatalf("expected an error for a CPU execution cap of %d", pct)
+		}
+	}
+
+	Teardown()
+}
+
+func TestGetMachineFlags(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the flags of TEST_VM are unknown")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "appliance", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+	)
+	ManageMock.EXPECT().runOut("--version").Return("7.0.8r156879\n", nil).Times(1)
+	var modifyArgs []string
+	ManageMock.EXPECT().run(gomock.Any()).DoAndReturn(func(args ...string) error {
+		modifyArgs = args
+		return nil
+	}).Times(1)
+
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Flag != ACPI|IOAPIC|NESTEDHWVIRT|ACCELERATE2D {
+		t.Fatalf("unexpected Flag %b", m.Flag)
+	}
+	if m.GraphicsController != GraphicsVMSVGA {
+		t.Fatalf("unexpected GraphicsController %q", m.GraphicsController)
+	}
+	if err := m.Modify(); err != nil {
+		t.Fatal(err)
+	}
+	for option, want := range map[string]string{
+		"--nested-hw-virt":     "on",
+		"--graphicscontroller": "vmsvga",
+		"--chipset":            "ich9",
+		"--tpm-type":           "2.0",
+		"--monitorcount":       "3",
+		"--accelerate2dvideo":  "on",
+		"--accelerate3d":       "off",
+	} {
+		if got := optionValue(modifyArgs, option); got != want {
+			t.Fatalf("unexpected %s %q in %v", option, got, modifyArgs)
+		}
+	}
+}
+
+func TestModifyMonitorCount(t *testing.T) {
+	m := &Machine{Name: VM, VRAM: 16, MonitorCount: 3}
+	err := m.validate()
+	if err == nil {
+		t.Fatal("expected an error for 16 MB of video memory with 3 monitors")
+	}
+	t.Log(err)
+}
+
+func TestModifyVRAM(t *testing.T) {
+	m := &Machine{Name: VM, VRAM: 512}
+	if err := m.validate(); err == nil {
+		t.Fatal("expected an error for 512 MB of video memory")
+	}
+	// Zero leaves the video memory unchanged.
+	m = &Machine{Name: VM, MonitorCount: 3}
+	if err := m.validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlagValidate(t *testing.T) {
+	valid := []Flag{0, ACPI | IOAPIC, HWVIRTEX | NESTEDPAGING | LARGEPAGES | VTXVPID | VTXUX, PAE | LONGMODE}
+	for _, f := range valid {
+		if err := f.Validate(); err != nil {
+			t.Fatalf("unexpected error for %d: %v", f, err)
+		}
+	}
+	invalid := []Flag{VTXVPID, LONGMODE, HWVIRTEX | LARGEPAGES, CPUHOTPLUG}
+	for _, f := range invalid {
+		if err := f.Validate(); !errors.Is(err, ErrInvalidFlags) {
+			t.Fatalf("expected ErrInvalidFlags for %d, got %v", f, err)
+		}
+	}
+}
+
+func TestFlagString(t *testing.T) {
+	tests := []struct {
+		flag Flag
+		s    string
+	}{
+		{0, "0"},
+		{ACPI | IOAPIC | PAE, "ACPI|IOAPIC|PAE"},
+		{NESTEDHWVIRT, "NESTEDHWVIRT"},
+		{ACCELERATE2D << 1, "Flag(0x10000)"},
+	}
+	for _, tt := range tests {
+		if s := tt.flag.String(); s != tt.s {
+			t.Errorf("expected %q, got %q", tt.s, s)
+		}
+	}
+
+	f, err := ParseFlags("acpi | IOAPIC|pae")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != ACPI|IOAPIC|PAE {
+		t.Fatalf("unexpected flags %v", f)
+	}
+	if f, err := ParseFlags(""); err != nil || f != 0 {
+		t.Fatalf("expected no flags, got %v, %v", f, err)
+	}
+	if _, err := ParseFlags("ACPI|TURBO"); err == nil {
+		t.Fatal("expected an error for an unknown flag")
+	}
+}
+
+func TestModifyStrictFlags(t *testing.T) {
+	StrictFlags = true
+	defer func() { StrictFlags = false }()
+
+	m := &Machine{Name: VM, VRAM: 16, Flag: VTXVPID}
+	if err := m.validate(); !errors.Is(err, ErrInvalidFlags) {
+		t.Fatalf("expected ErrInvalidFlags, got %v", err)
+	}
+}
+
+func TestModifyRecentFlags(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the flags would change the settings of TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	var modifyArgs [][]string
+	ManageMock.EXPECT().run(gomock.Any()).DoAndReturn(func(args ...string) error {
+		modifyArgs = append(modifyArgs, args)
+		return nil
+	}).Times(2)
+	ManageMock.EXPECT().runOutErr("showvminfo", gomock.Any(), "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+
+	// VirtualBox 5.2 does not know --nested-hw-virt.
+	m := &Machine{Name: VM, VRAM: 16, Flag: ACPI}
+	if err := m.Modify(); err != nil {
+		t.Fatal(err)
+	}
+	m = &Machine{Name: VM, VRAM: 16, Flag: ACPI | NESTEDHWVIRT}
+	if err := m.Modify(); err != nil {
+		t.Fatal(err)
+	}
+	if got := optionValue(modifyArgs[0], "--nested-hw-virt"); got != "" {
+		t.Errorf("unexpected --nested-hw-virt %q for an unset flag", got)
+	}
+	if got := optionValue(modifyArgs[0], "--acpi"); got != "on" {
+		t.Errorf("expected --acpi on, got %q", got)
+	}
+	if got := optionValue(modifyArgs[1], "--nested-hw-virt"); got != "on" {
+		t.Errorf("expected --nested-hw-virt on, got %q", got)
+	}
+}
+
+func TestParseTPMType(t *testing.T) {
+	for in, want := range map[string]TPMType{"none": "", "v1_2": TPM12, "v2_0": TPM20, "host": TPMHost} {
+		if got := parseTPMType(in); got != want {
+			t.Errorf("parseTPMType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestModifyTPM(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("a TPM would change the settings of TEST_VM")
+	}
+	ManageMock.EXPECT().runOut("--version").Return("6.1.26r145957\n", nil).Times(1)
+	m := &Machine{Name: VM, VRAM: 16, TPMType: TPM20}
+	err := m.Modify()
+	if err == nil {
+		t.Fatal("expected an error with VirtualBox 6.1")
+	}
+	t.Log(err)
+}
+
+func TestModifyFirmware(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the firmware would change the settings of TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	var modifyArgs [][]string
+	ManageMock.EXPECT().run(gomock.Any()).DoAndReturn(func(args ...string) error {
+		modifyArgs = append(modifyArgs, args)
+		return nil
+	}).Times(2)
+	ManageMock.EXPECT().runOutErr("showvminfo", gomock.Any(), "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+
+	m := &Machine{Name: VM, VRAM: 16, Firmware: FirmwareEFI64}
+	if err := m.Modify(); err != nil {
+		t.Fatal(err)
+	}
+	// An empty Firmware falls back to the BIOS.
+	m = &Machine{Name: VM, VRAM: 16}
+	if err := m.Modify(); err != nil {
+		t.Fatal(err)
+	}
+	if got := optionValue(modifyArgs[0], "--firmware"); got != "efi64" {
+		t.Errorf("expected --firmware efi64, got %q", got)
+	}
+	if got := optionValue(modifyArgs[1], "--firmware"); got != "bios" {
+		t.Errorf("expected --firmware bios, got %q", got)
+	}
+	for option, want := range map[string]string{
+		"--bioslogofadein":      "off",
+		"--bioslogofadeout":     "off",
+		"--bioslogodisplaytime": "0",
+		"--biosbootmenu":        "disabled",
+	} {
+		if got := optionValue(modifyArgs[0], option); got != "" {
+			t.Errorf("unexpected %s %q with EFI", option, got)
+		}
+		if got := optionValue(modifyArgs[1], option); got != want {
+			t.Errorf("expected %s %s with the BIOS, got %q", option, want, got)
+		}
+	}
+}
+
+// optionValue returns the value following option in args.
+func optionValue(args []string, option string) string {
+	for i, arg := range args {
+		if arg == option && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func TestListNATPF(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the port forwarding rules of TEST_VM are unknown")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+
+	m := &Machine{Name: VM}
+	rules, err := m.ListNATPF(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].Name != "ssh" || rules[1].Name != "dns" {
+		t.Fatalf("unexpected rules %+v", rules)
+	}
+	if r := rules[1].Rule; r.Proto != PFUDP || !r.HostIP.Equal(net.ParseIP("127.0.0.1")) || r.HostPort != 5353 || r.GuestPort != 53 {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+	rules, err = m.ListNATPF(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 0 {
+		t.Fatalf("expected no rules, got %+v", rules)
+	}
+}
+
+func TestSetNIC(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("changing the NICs would break the network of TEST_VM")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("modifyvm", VM, "--nic2", "intnet", "--nictype2", "82540EM", "--cableconnected2", "on",
+			"--macaddress2", "auto", "--intnet2", "backend").
+			Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", VM, "--nic3", "generic", "--nictype3", "virtio", "--cableconnected3", "on",
+			"--macaddress3", "080027EE1DF7", "--nicgenericdrv3", "UDPTunnel").
+			Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM}
+	if err := m.SetNIC(2, NIC{Network: NICNetInternal, Hardware: IntelPro1000MTDesktop, HostInterface: "backend"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetNIC(3, NIC{Network: NICNetGeneric, Hardware: VirtIO, HostInterface: "UDPTunnel", MacAddr: "08:00:27:ee:1d:f7"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetNIC(4, NIC{Network: NICNetNAT, Hardware: VirtIO, MacAddr: "08:00:27:ee:1d"}); err == nil {
+		t.Fatal("expected an error for an invalid MAC address")
+	}
+}
+
+func TestSetCableConnected(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("pulling the cable would break the network of TEST_VM")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("controlvm", VM, "setlinkstate1", "off").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", VM, "--cableconnected1", "on").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Running}
+	if err := m.SetCableConnected(1, false); err != nil {
+		t.Fatal(err)
+	}
+	m.State = Poweroff
+	if err := m.SetCableConnected(1, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetNICTrace(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("tracing would change the settings of TEST_VM")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("controlvm", VM, "nictracefile2", "/tmp/nic2.pcap").Return(nil).Times(1),
+		ManageMock.EXPECT().run("controlvm", VM, "nictrace2", "on").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", VM, "--nictrace1", "on", "--nictracefile1", "/tmp/nic1.pcap").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", VM, "--nictrace1", "off").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Running}
+	if err := m.SetNICTrace(2, true, "/tmp/nic2.pcap"); err != nil {
+		t.Fatal(err)
+	}
+	m.State = Poweroff
+	if err := m.SetNICTrace(1, true, "/tmp/nic1.pcap"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetNICTrace(1, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetNICTrace(0, true, ""); err == nil {
+		t.Fatal("expected an error for NIC 0")
+	}
+}
+
+func TestSetBootOrder(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("changing the boot order could leave TEST_VM unbootable")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", "go-virtualbox", "--boot1", "net", "--boot2", "disk", "--boot3", "none", "--boot4", "none").
+			Return(nil).Times(1),
+	)
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.BootOrder) != 2 || m.BootOrder[0] != "disk" || m.BootOrder[1] != "dvd" {
+		t.Fatalf("unexpected boot order %v", m.BootOrder)
+	}
+	if err := m.SetBootOrder("net", "disk"); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.BootOrder) != 2 || m.BootOrder[0] != "net" {
+		t.Fatalf("unexpected boot order %v", m.BootOrder)
+	}
+	if err := m.SetBootOrder("usb"); err == nil {
+		t.Fatal("expected an error for an invalid boot device")
+	}
+}
+
+func TestListMachineNames(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+		ManageMock.EXPECT().runOut("list", "vms").Return(listVmsOut, nil).Times(1)
+	}
+	refs, err := ListMachineNames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", refs)
+	if ManageMock != nil {
+		want := []MachineRef{
+			{Name: "Ubuntu", UUID: "2e16b1fc-675d-4a7a-a9a1-e89a8bde7874"},
+			{Name: "go-virtualbox", UUID: "def44546-e3da-4902-8d15-b91c99c80cbc"},
+		}
+		if !reflect.DeepEqual(refs, want) {
+			t.Fatalf("expected %+v, got %+v", want, refs)
+		}
+	}
+
+	Teardown()
+}
+
+func TestListMachinesParallel(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock != nil {
+		listVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+		ManageMock.EXPECT().runOut("list", "vms").Return(listVmsOut, nil).Times(1)
+		ManageMock.EXPECT().runOutErr("showvminfo", "2e16b1fc-675d-4a7a-a9a1-e89a8bde7874", "--machinereadable").
+			Return(ReadTestData("vboxmanage-showvminfo-3.out"), "", nil).Times(1)
+		ManageMock.EXPECT().runOutErr("showvminfo", "def44546-e3da-4902-8d15-b91c99c80cbc", "--machinereadable").
+			Return("", "VBoxManage: error: Could not find a registered machine with UUID {def44546-e3da-4902-8d15-b91c99c80cbc}\n", errors.New("exit status 1")).Times(1)
+	}
+	ms, err := ListMachinesParallel(context.Background(), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", ms)
+	if ManageMock != nil && (len(ms) != 1 || ms[0].Name != "appliance") {
+		t.Fatalf("unexpected machines %+v", ms)
+	}
+}
+
+func TestListMachinesByGroup(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the groups of the registered machines are unknown")
+	}
+	listVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOut("list", "vms").Return(listVmsOut, nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").
+			Return(ReadTestData("vboxmanage-showvminfo-1.out"), "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").
+			Return(ReadTestData("vboxmanage-showvminfo-3.out"), "", nil).Times(1),
+	)
+	ms, err := ListMachinesByGroup("/Production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 1 || ms[0].Name != "appliance" {
+		t.Fatalf("unexpected machines %+v", ms)
+	}
+	if len(ms[0].Groups) != 2 || ms[0].Groups[1] != "/Backup" {
+		t.Fatalf("unexpected groups %v", ms[0].Groups)
+	}
+}
+
+func TestCloneMachineOpts(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("cloning would leave a machine behind")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().run("clonevm", "base", "--name", "appliance", "--register",
+			"--snapshot", "golden", "--mode", "machine", "--options", "link", "--basefolder", "/vms").Return(nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "appliance", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+	)
+	m, err := CloneMachineOpts("base", CloneOptions{
+		Name:       "appliance",
+		Snapshot:   "golden",
+		Mode:       CloneModeMachine,
+		Linked:     true,
+		BaseFolder: "/vms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "appliance" {
+		t.Fatalf("unexpected clone %+v", m)
+	}
+
+	if _, err := CloneMachineOpts("base", CloneOptions{Name: "linked", Linked: true}); err == nil {
+		t.Fatal("expected an error for a linked clone without snapshot")
+	}
+}
+
+func TestSetExtraDataContext(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM extra data would be changed")
+	}
+	ManageMock.EXPECT().run("setextradata", VM, "owner", "ci").Return(errors.New("signal: killed")).Times(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m := &Machine{Name: VM}
+	if err := m.SetExtraDataContext(ctx, "owner", "ci"); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDeleteExtraData(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().run("setextradata", VM, "go-virtualbox/test", "value").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("getextradata", VM, "go-virtualbox/test").Return("Value: value\n", nil).Times(1),
+			ManageMock.EXPECT().run("setextradata", VM, "go-virtualbox/test").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("getextradata", VM, "go-virtualbox/test").Return("No value set!\n", nil).Times(1),
+		)
+	}
+	m := &Machine{Name: VM}
+	if err := m.SetExtraData("go-virtualbox/test", "value"); err != nil {
+		t.Fatal(err)
+	}
+	val, err := m.GetExtraData("go-virtualbox/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val == nil || *val != "value" {
+		t.Fatalf("unexpected value %v", val)
+	}
+	if err := m.DeleteExtraData("go-virtualbox/test"); err != nil {
+		t.Fatal(err)
+	}
+	val, err = m.GetExtraData("go-virtualbox/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != nil {
+		t.Fatalf("key was not deleted, value %q", *val)
+	}
+
+	Teardown()
+}
+
+func TestCreateMachine(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("creating would leave a machine behind")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-4.out")
+	var modifyArgs []string
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("createvm", "--name", "fresh", "--register", "--basefolder", "/vms").
+			Return("Virtual machine 'fresh' is created and registered.\n"+
+				"UUID: 0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d\n"+
+				"Settings file: '/vms/fresh/fresh.vbox'\n", "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d", "--machinereadable").
+			Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().run(gomock.Any()).DoAndReturn(func(args ...string) error {
+			modifyArgs = args
+			return nil
+		}).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "fresh", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("createvm", "--name", "fresh", "--register").
+			Return("", "VBoxManage: error: Machine settings file '/home/user/VirtualBox VMs/fresh/fresh.vbox' already exists\n", errors.New("exit status 1")).Times(1),
+	)
+	m, err := CreateMachine("fresh", "/vms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "fresh" || m.UUID != "0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d" || m.CfgFile != "/vms/fresh/fresh.vbox" || m.State != Poweroff {
+		t.Fatalf("unexpected machine %+v", m)
+	}
+
+	// The defaults of VirtualBox are kept.
+	m.CPUs = 2
+	if err := m.Modify(); err != nil {
+		t.Fatal(err)
+	}
+	for option, want := range map[string]string{
+		"--ostype": "Other",
+		"--cpus":   "2",
+		"--memory": "128",
+		"--vram":   "8",
+		"--acpi":   "on",
+		"--ioapic": "on",
+	} {
+		if got := optionValue(modifyArgs, option); got != want {
+			t.Errorf("expected %s %s, got %q", option, want, got)
+		}
+	}
+	if _, err := CreateMachine("fresh", ""); err != ErrMachineExist {
+		t.Fatalf("expected ErrMachineExist, got %v", err)
+	}
+}
+
+func TestCreateMachineOpts(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("creating would leave a machine behind")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("createvm", "--name", "fresh", "--register", "--ostype", "Ubuntu_64",
+			"--groups", "/Production/web", "--uuid", "0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d", "--default").
+			Return("Virtual machine 'fresh' is created and registered.\n"+
+				"UUID: 0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d\n"+
+				"Settings file: '/home/user/VirtualBox VMs/Production/web/fresh/fresh.vbox'\n", "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d", "--machinereadable").
+			Return(ReadTestData("vboxmanage-showvminfo-4.out"), "", nil).Times(1),
+	)
+	m, err := CreateMachineOpts(CreateOptions{
+		Name:    "fresh",
+		OSType:  "Ubuntu_64",
+		Groups:  []string{"/Production/web"},
+		UUID:    "0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d",
+		Default: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "fresh" || m.UUID != "0f7b2c1d-9a3e-4b5f-8c6d-7e8f9a0b1c2d" {
+		t.Fatalf("unexpected machine %+v", m)
+	}
+}
+
+func TestMachineCopy(t *testing.T) {
+	m := New()
+	m.Name = "template"
+	m.BootOrder = append(m.BootOrder, "disk")
+	m.NICs = append(m.NICs, NIC{Network: NICNetNAT, Hardware: VirtIO})
+	m.Groups = []string{"/test"}
+
+	c := m.Copy()
+	c.Name = "copy"
+	c.BootOrder = append(c.BootOrder, "net")
+	c.NICs[0].Network = NICNetBridged
+	c.Groups[0] = "/prod"
+
+	if m.Name != "template" || len(m.BootOrder) != 1 || m.BootOrder[:2][1] != "" {
+		t.Fatalf("boot order of the original changed: %+v", m)
+	}
+	if m.NICs[0].Network != NICNetNAT || m.Groups[0] != "/test" {
+		t.Fatalf("original changed: %+v", m)
+	}
+	if c.BootOrder[1] != "net" || c.NICs[0].Network != NICNetBridged {
+		t.Fatalf("unexpected copy %+v", c)
+	}
+	if New().Copy().USBFilters != nil {
+		t.Fatal("nil slices should stay nil")
+	}
+}
+
+func TestQuickClone(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("cloning would leave a machine behind")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().run("clonevm", "base", "--name", "go-virtualbox", "--register",
+			"--snapshot", "golden", "--options", "link").Return(nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", "go-virtualbox", "--cpus", "4", "--memory", "2048").Return(nil).Times(1),
+		ManageMock.EXPECT().runOutErr("startvm", "go-virtualbox", "--type", "headless").Return("", "", nil).Times(1),
+	)
+	m, err := QuickClone("base", "go-virtualbox", QuickCloneOptions{Snapshot: "golden", CPUs: 4, Memory: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.CPUs != 4 || m.Memory != 2048 || m.State != Running {
+		t.Fatalf("unexpected clone %+v", m)
+	}
+}
+
+func TestQuickCloneCleanup(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("cloning would leave a machine behind")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().run("clonevm", "base", "--name", "go-virtualbox", "--register",
+			"--snapshot", "golden", "--options", "link").Return(nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("startvm", "go-virtualbox", "--type", "headless").
+			Return("", "VBoxManage: error: The machine is locked", errors.New("exit status 1")).Times(1),
+		ManageMock.EXPECT().run("unregistervm", "go-virtualbox", "--delete").Return(nil).Times(1),
+	)
+	if _, err := QuickClone("base", "go-virtualbox", QuickCloneOptions{Snapshot: "golden"}); err == nil {
+		t.Fatal("expected an error when the clone cannot start")
+	}
+}
@@ -0,0 +1,220 @@
+package virtualbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reMediumInUse    = regexp.MustCompile(`is still attached to|because it is attached to`)
+	reMediumCapacity = regexp.MustCompile(`^(\d+) MBytes`)
+)
+
+var (
+	// ErrMediumInUse is returned when the medium is still attached to a registered machine.
+	ErrMediumInUse = errors.New("medium is attached to a machine")
+)
+
+// MediumType represents the kind of device a medium is used with.
+type MediumType string
+
+const (
+	// MediumDisk when the medium is a hard disk image.
+	MediumDisk = MediumType("disk")
+	// MediumDVD when the medium is a DVD/CD image.
+	MediumDVD = MediumType("dvd")
+	// MediumFloppy when the medium is a floppy image.
+	MediumFloppy = MediumType("floppy")
+)
+
+// CreateMedium creates a new virtual disk image at path with the given size
+// in MB. The format defaults to VDI and the variant to Standard when empty.
+// It refuses to overwrite an existing file.
+func CreateMedium(path string, sizeMB uint, format string, variant string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("cannot create medium %s: %w", path, os.ErrExist)
+	}
+	if format == "" {
+		format = "VDI"
+	}
+	if variant == "" {
+		variant = "Standard"
+	}
+	return Manage().run("createmedium", "disk", "--filename", path,
+		"--size", fmt.Sprintf("%d", sizeMB),
+		"--format", format,
+		"--variant", variant,
+	)
+}
+
+// CloseMedium unregisters the medium with the given path or UUID. When delete
+// is true, the image file is deleted as well.
+func CloseMedium(mediumType MediumType, id string, delete bool) error {
+	args := []string{"closemedium", string(mediumType), id}
+	if delete {
+		args = append(args, "--delete")
+	}
+	_, stderr, err := Manage().runOutErr(args...)
+	if err != nil {
+		if reMediumInUse.MatchString(stderr) {
+			return ErrMediumInUse
+		}
+		return err
+	}
+	return nil
+}
+
+// ResizeMedium grows the disk image at path to newSizeMB. Shrinking is not
+// supported by VirtualBox, so it is rejected with an error.
+func ResizeMedium(path string, newSizeMB uint) error {
+	medium, err := MediumInfo(path)
+	if err != nil {
+		return err
+	}
+	if size := medium.CapacityMB; newSizeMB <= size {
+		return fmt.Errorf("cannot resize medium %s from %d MB to %d MB: only growing is supported", path, size, newSizeMB)
+	}
+	return Manage().run("modifymedium", "disk", path, "--resize", fmt.Sprintf("%d", newSizeMB))
+}
+
+// MediumMode is how a disk image behaves when attached to machines and with
+// snapshots, VirtualBox calls it the medium type.
+type MediumMode string
+
+const (
+	// MediumNormal is a MediumMode value.
+	MediumNormal = MediumMode("normal")
+	// MediumImmutable is a MediumMode value.
+	MediumImmutable = MediumMode("immutable")
+	// MediumWritethrough is a MediumMode value.
+	MediumWritethrough = MediumMode("writethrough")
+	// MediumShareable is a MediumMode value.
+	MediumShareable = MediumMode("shareable")
+	// MediumReadonly is a MediumMode value.
+	MediumReadonly = MediumMode("readonly")
+	// MediumMultiattach is a MediumMode value.
+	MediumMultiattach = MediumMode("multiattach")
+)
+
+// SetMediumType changes the type of the disk image at path, e.g. to make an
+// immutable or multiattach base image. The image must not be attached to any
+// machine.
+func SetMediumType(path string, typ MediumMode) error {
+	switch typ {
+	case MediumNormal, MediumImmutable, MediumWritethrough, MediumShareable, MediumReadonly, MediumMultiattach:
+	default:
+		return fmt.Errorf("invalid type %q for medium %s", typ, path)
+	}
+	_, stderr, err := Manage().runOutErr("modifymedium", "disk", path, "--type", string(typ))
+	if err != nil {
+		if reMediumInUse.MatchString(stderr) {
+			return ErrMediumInUse
+		}
+		return err
+	}
+	return nil
+}
+
+// Medium holds the information of a disk image.
+type Medium struct {
+	UUID         string
+	ParentUUID   string // empty unless a differencing image
+	Location     string
+	Format       string // e.g. VDI or VMDK
+	CapacityMB   uint   // logical size
+	SizeOnDiskMB uint
+	Type         MediumMode
+	Encrypted    bool
+}
+
+// MediumInfo returns the information of the disk image at path.
+func MediumInfo(path string) (*Medium, error) {
+	out, err := Manage().runOut("showmediuminfo", "disk", path)
+	if err != nil {
+		return nil, err
+	}
+	medium := &Medium{}
+	capacity := false
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		switch key, val := res[1], res[2]; key {
+		case "UUID":
+			medium.UUID = val
+		case "Parent UUID":
+			if val != "base" {
+				medium.ParentUUID = val
+			}
+		case "Location":
+			medium.Location = val
+		case "Storage format":
+			medium.Format = val
+		case "Type":
+			// e.g. 'normal (base)' or 'normal (differencing)'
+			if fields := strings.Fields(val); len(fields) > 0 {
+				medium.Type = MediumMode(fields[0])
+			}
+		case "Capacity", "Logical size":
+			if medium.CapacityMB, err = parseMediumSize(path, val); err != nil {
+				return nil, err
+			}
+			capacity = true
+		case "Size on disk", "Current size on disk":
+			if medium.SizeOnDiskMB, err = parseMediumSize(path, val); err != nil {
+				return nil, err
+			}
+		case "Encryption":
+			medium.Encrypted = val == "enabled"
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !capacity {
+		return nil, fmt.Errorf("could not find the capacity of medium %s", path)
+	}
+	return medium, nil
+}
+
+// parseMediumSize parses a size such as '10240 MBytes' into MB.
+func parseMediumSize(path, val string) (uint, error) {
+	res := reMediumCapacity.FindStringSubmatch(val)
+	if res == nil {
+		return 0, fmt.Errorf("could not parse size %q of medium %s", val, path)
+	}
+	n, err := strconv.ParseUint(res[1], 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(n), nil
+}
+
+// CloneMedium copies the disk image src to dst in the given format, registers
+// it, and returns the UUID of the new medium. When linked is true, dst is
+// created as a differencing image of src.
+func CloneMedium(src, dst string, format string, linked bool) (string, error) {
+	args := []string{"clonemedium", "disk", src, dst}
+	if format != "" {
+		args = append(args, "--format", format)
+	}
+	if linked {
+		args = append(args, "--variant", "Diff")
+	}
+	out, err := Manage().runOut(args...)
+	if err != nil {
+		return "", err
+	}
+	res := reUUID.FindStringSubmatch(out)
+	if res == nil {
+		return "", fmt.Errorf("could not find the UUID of medium %s", dst)
+	}
+	return res[1], nil
+}
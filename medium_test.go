@@ -0,0 +1,161 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestCreateMedium(t *testing.T) {
+	Setup(t)
+
+	dir, err := ioutil.TempDir("", "go-virtualbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	disk := filepath.Join(dir, "data.vdi")
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().run("createmedium", "disk", "--filename", disk,
+			"--size", "1024", "--format", "VDI", "--variant", "Standard").Return(nil).Times(1)
+	}
+	if err := CreateMedium(disk, 1024, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	existing := filepath.Join(dir, "existing.vdi")
+	if err := ioutil.WriteFile(existing, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateMedium(existing, 1024, "", ""); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected os.ErrExist, got %v", err)
+	}
+
+	Teardown()
+}
+
+func TestCloseMedium(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("closemedium", "disk", "/tmp/data.vdi", "--delete").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("closemedium", "dvd", "/tmp/install.iso").
+				Return("", "VBoxManage: error: Cannot close medium '/tmp/install.iso' because it is still attached to 1 virtual machines\n",
+					errors.New("exit status 1")).Times(1),
+		)
+	}
+	if err := CloseMedium(MediumDisk, "/tmp/data.vdi", true); err != nil {
+		t.Fatal(err)
+	}
+	if ManageMock != nil {
+		if err := CloseMedium(MediumDVD, "/tmp/install.iso", false); err != ErrMediumInUse {
+			t.Fatalf("expected ErrMediumInUse, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestResizeMedium(t *testing.T) {
+	Setup(t)
+
+	disk := "/Users/fix/VirtualBox VMs/go-virtualbox/ubuntu-16.04-amd64-disk001.vmdk"
+	if ManageMock != nil {
+		mediumInfoOut := ReadTestData("vboxmanage-showmediuminfo-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("showmediuminfo", "disk", disk).Return(mediumInfoOut, nil).Times(1),
+			ManageMock.EXPECT().run("modifymedium", "disk", disk, "--resize", "20480").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("showmediuminfo", "disk", disk).Return(mediumInfoOut, nil).Times(1),
+		)
+	}
+	if err := ResizeMedium(disk, 20480); err != nil {
+		t.Fatal(err)
+	}
+	if ManageMock != nil {
+		err := ResizeMedium(disk, 4096)
+		if err == nil {
+			t.Fatal("expected shrinking to fail")
+		}
+		t.Log(err)
+	}
+
+	Teardown()
+}
+
+func TestMediumInfo(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the disk images of TEST_VM are unknown")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().runOut("showmediuminfo", "disk", "base.vmdk").
+			Return(ReadTestData("vboxmanage-showmediuminfo-1.out"), nil).Times(1),
+		ManageMock.EXPECT().runOut("showmediuminfo", "disk", "diff.vmdk").
+			Return(ReadTestData("vboxmanage-showmediuminfo-2.out"), nil).Times(1),
+	)
+	medium, err := MediumInfo("base.vmdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if medium.UUID != "32583b48-693e-45d4-882f-e9196d4f43c6" || medium.ParentUUID != "" || medium.Format != "VMDK" ||
+		medium.CapacityMB != 10240 || medium.SizeOnDiskMB != 2018 || medium.Type != MediumNormal || medium.Encrypted {
+		t.Fatalf("unexpected medium %+v", medium)
+	}
+	medium, err = MediumInfo("diff.vmdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if medium.ParentUUID != "32583b48-693e-45d4-882f-e9196d4f43c6" || medium.Type != MediumImmutable || !medium.Encrypted {
+		t.Fatalf("unexpected medium %+v", medium)
+	}
+}
+
+func TestSetMediumType(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the disk images of TEST_VM are unknown")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("modifymedium", "disk", "base.vdi", "--type", "multiattach").Return("", "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("modifymedium", "disk", "base.vdi", "--type", "immutable").
+			Return("", "VBoxManage: error: Cannot change the type of medium 'base.vdi' because it is attached to 1 virtual machines\n", errors.New("exit status 1")).Times(1),
+	)
+	if err := SetMediumType("base.vdi", MediumMultiattach); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetMediumType("base.vdi", MediumImmutable); err != ErrMediumInUse {
+		t.Fatalf("expected ErrMediumInUse, got %v", err)
+	}
+	if err := SetMediumType("base.vdi", MediumMode("golden")); err == nil {
+		t.Fatal("expected an error for an invalid type")
+	}
+}
+
+func TestCloneMedium(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().runOut("clonemedium", "disk", "/tmp/base.vdi", "/tmp/clone.vdi", "--format", "VDI", "--variant", "Diff").
+			Return("0%...10%...20%...30%...40%...50%...60%...70%...80%...90%...100%\n"+
+				"Clone medium created in format 'VDI'. UUID: 1c0e2a59-3f6b-4d8e-9a7c-5b4d3e2f1a0b\n", nil).Times(1)
+	}
+	id, err := CloneMedium("/tmp/base.vdi", "/tmp/clone.vdi", "VDI", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ManageMock != nil && id != "1c0e2a59-3f6b-4d8e-9a7c-5b4d3e2f1a0b" {
+		t.Fatalf("unexpected medium UUID %q", id)
+	}
+
+	Teardown()
+}
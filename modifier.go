@@ -0,0 +1,62 @@
+package virtualbox
+
+import (
+	"context"
+	"fmt"
+)
+
+// VMModifier accumulates machine settings to change, and applies only those
+// with a single 'VBoxManage modifyvm' call. Unlike Machine.Modify, settings
+// that were not explicitly set are left untouched.
+type VMModifier struct {
+	m    *Machine
+	args []string
+}
+
+// Modifier returns a VMModifier for the machine.
+func (m *Machine) Modifier() *VMModifier {
+	return &VMModifier{m: m}
+}
+
+func (v *VMModifier) set(option, value string) *VMModifier {
+	v.args = append(v.args, option, value)
+	return v
+}
+
+// Memory sets the main memory size in MB.
+func (v *VMModifier) Memory(mb uint) *VMModifier {
+	return v.set("--memory", fmt.Sprintf("%d", mb))
+}
+
+// CPUs sets the number of virtual CPUs.
+func (v *VMModifier) CPUs(n uint) *VMModifier {
+	return v.set("--cpus", fmt.Sprintf("%d", n))
+}
+
+// VRAM sets the video memory size in MB.
+func (v *VMModifier) VRAM(mb uint) *VMModifier {
+	return v.set("--vram", fmt.Sprintf("%d", mb))
+}
+
+// OSType sets the guest OS type.
+func (v *VMModifier) OSType(osType string) *VMModifier {
+	return v.set("--ostype", osType)
+}
+
+// Firmware sets the firmware used to boot the machine.
+func (v *VMModifier) Firmware(firmware Firmware) *VMModifier {
+	return v.set("--firmware", string(firmware))
+}
+
+// Apply changes the settings of the machine and refreshes it. It does nothing
+// when no setting was changed.
+func (v *VMModifier) Apply(ctx context.Context) error {
+	if len(v.args) == 0 {
+		return nil
+	}
+	args := append([]string{"modifyvm", v.m.Name}, v.args...)
+	if err := Manage().setOpts(withContext(ctx)).run(args...); err != nil {
+		return err
+	}
+	return v.m.Refresh()
+}
@@ -0,0 +1,30 @@
+package virtualbox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestModifier(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().run("modifyvm", VM, "--memory", "2048", "--cpus", "2").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		)
+	}
+	m := &Machine{Name: VM}
+	if err := m.Modifier().Memory(2048).CPUs(2).Apply(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing to change, so nothing is run.
+	if err := m.Modifier().Apply(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	Teardown()
+}
@@ -2,6 +2,7 @@ package virtualbox
 
 import (
 	"bufio"
+	"fmt"
 	"net"
 	"strings"
 )
@@ -15,19 +16,80 @@ type NATNet struct {
 	Enabled bool
 }
 
+// CreateNATNetwork creates the NAT network n.Name on the n.IPv4 network.
+func CreateNATNetwork(n NATNet) error {
+	if n.Name == "" {
+		return fmt.Errorf("NAT network name is empty")
+	}
+	if n.IPv4.IP == nil || n.IPv4.Mask == nil {
+		return fmt.Errorf("NAT network %s has no IPv4 network", n.Name)
+	}
+	network := net.IPNet{IP: n.IPv4.IP.Mask(n.IPv4.Mask), Mask: n.IPv4.Mask}
+	args := []string{"natnetwork", "add", "--netname", n.Name, "--network", network.String()}
+	if n.Enabled {
+		args = append(args, "--enable")
+	} else {
+		args = append(args, "--disable")
+	}
+	args = append(args, "--dhcp", bool2string(n.DHCP))
+	return Manage().run(args...)
+}
+
+// RemoveNATNetwork removes the named NAT network.
+func RemoveNATNetwork(name string) error {
+	return Manage().run("natnetwork", "remove", "--netname", name)
+}
+
+// AddNATNetworkPF adds a port forwarding rule with the given name to the
+// named NAT network.
+func AddNATNetworkPF(netName, ruleName string, rule PFRule) error {
+	hostip, guestip := grab(rule)
+	ipv6 := (rule.HostIP != nil && rule.HostIP.To4() == nil) || (rule.GuestIP != nil && rule.GuestIP.To4() == nil)
+	return Manage().run("natnetwork", "modify", "--netname", netName, pfOption(ipv6),
+		fmt.Sprintf("%s:%s:[%s]:%d:[%s]:%d", ruleName, rule.Proto, hostip, rule.HostPort, guestip, rule.GuestPort))
+}
+
+// DelNATNetworkPF deletes the IPv4, or IPv6, port forwarding rule with the
+// given name from the named NAT network.
+func DelNATNetworkPF(netName, ruleName string, ipv6 bool) error {
+	return Manage().run("natnetwork", "modify", "--netname", netName, pfOption(ipv6), "delete", ruleName)
+}
+
+func pfOption(ipv6 bool) string {
+	if ipv6 {
+		return "--port-forward-6"
+	}
+	return "--port-forward-4"
+}
+
 // NATNets gets all NAT networks in a  map keyed by NATNet.Name.
 func NATNets() (map[string]NATNet, error) {
+	nets, err := ListNATNetworks()
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]NATNet{}
+	for _, n := range nets {
+		m[n.Name] = n
+	}
+	return m, nil
+}
+
+// ListNATNetworks gets all NAT networks.
+func ListNATNetworks() ([]NATNet, error) {
 	out, err := Manage().runOut("list", "natnets")
 	if err != nil {
 		return nil, err
 	}
 	s := bufio.NewScanner(strings.NewReader(out))
-	m := map[string]NATNet{}
+	nets := []NATNet{}
 	n := NATNet{}
 	for s.Scan() {
 		line := s.Text()
 		if line == "" {
-			m[n.Name] = n
+			if n.Name != "" {
+				nets = append(nets, n)
+			}
 			n = NATNet{}
 			continue
 		}
@@ -70,5 +132,8 @@ func NATNets() (map[string]NATNet, error) {
 	if err := s.Err(); err != nil {
 		return nil, err
 	}
-	return m, nil
+	if n.Name != "" {
+		nets = append(nets, n)
+	}
+	return nets, nil
 }
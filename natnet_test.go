@@ -1,6 +1,7 @@
 package virtualbox
 
 import (
+	"net"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -23,3 +24,46 @@ func TestNATNets(t *testing.T) {
 
 	Teardown()
 }
+
+func TestNATNetworkLifecycle(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("creating a NAT network would change the host")
+	}
+	listNatNetsOut := ReadTestData("vboxmanage-list-natnets-1.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().run("natnetwork", "add", "--netname", "NatNetwork", "--network", "10.0.2.0/24", "--enable", "--dhcp", "on").
+			Return(nil).Times(1),
+		ManageMock.EXPECT().run("natnetwork", "modify", "--netname", "NatNetwork", "--port-forward-4", "ssh:tcp:[]:2222:[10.0.2.15]:22").
+			Return(nil).Times(1),
+		ManageMock.EXPECT().run("natnetwork", "modify", "--netname", "NatNetwork", "--port-forward-4", "delete", "ssh").
+			Return(nil).Times(1),
+		ManageMock.EXPECT().runOut("list", "natnets").Return(listNatNetsOut, nil).Times(1),
+		ManageMock.EXPECT().run("natnetwork", "remove", "--netname", "NatNetwork").Return(nil).Times(1),
+	)
+
+	_, ipnet, _ := net.ParseCIDR("10.0.2.1/24")
+	ipnet.IP = net.ParseIP("10.0.2.1")
+	if err := CreateNATNetwork(NATNet{Name: "NatNetwork", IPv4: *ipnet, DHCP: true, Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	rule := PFRule{Proto: PFTCP, HostPort: 2222, GuestIP: net.ParseIP("10.0.2.15"), GuestPort: 22}
+	if err := AddNATNetworkPF("NatNetwork", "ssh", rule); err != nil {
+		t.Fatal(err)
+	}
+	if err := DelNATNetworkPF("NatNetwork", "ssh", false); err != nil {
+		t.Fatal(err)
+	}
+	nets, err := ListNATNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nets) != 1 || nets[0].Name != "NatNetwork" || !nets[0].DHCP || !nets[0].Enabled {
+		t.Fatalf("unexpected NAT networks %+v", nets)
+	}
+	if err := RemoveNATNetwork("NatNetwork"); err != nil {
+		t.Fatal(err)
+	}
+}
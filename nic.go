@@ -1,11 +1,26 @@
 package virtualbox
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	reMACAddr = regexp.MustCompile(`^[0-9A-F]{12}$`)
+)
+
+// MaxNICs is the number of network adapters a machine can have.
+const MaxNICs = 8
+
 // NIC represents a virtualized network interface card.
 type NIC struct {
-	Network       NICNetwork
-	Hardware      NICHardware
-	HostInterface string // The host interface name to bind to in 'hostonly' and 'bridged' mode
-	MacAddr       string
+	Network        NICNetwork
+	Hardware       NICHardware
+	HostInterface  string // The host interface name to bind to in 'hostonly' and 'bridged' mode, the network name in 'natnetwork' and 'intnet' mode, or the driver in 'generic' mode
+	MacAddr        string // generated when empty or "auto"
+	BandwidthGroup string // name of the network bandwidth group limiting the NIC, if any
+	PromiscMode    string // one of {deny|allow-vms|allow-all}, unchanged when empty
 }
 
 // NICNetwork represents the type of NIC networks.
@@ -18,6 +33,8 @@ const (
 	NICNetDisconnected = NICNetwork("null")
 	// NICNetNAT when the NIC is NAT-ed to access the external network.
 	NICNetNAT = NICNetwork("nat")
+	// NICNetNATNetwork when the NIC is NAT-ed through a NAT network shared with other VMs.
+	NICNetNATNetwork = NICNetwork("natnetwork")
 	// NICNetBridged when the NIC is the bridge to the external network.
 	NICNetBridged = NICNetwork("bridged")
 	// NICNetInternal when the NIC does not have access to the external network.
@@ -45,3 +62,54 @@ const (
 	// VirtIO when the NIC emulates a virtio.
 	VirtIO = NICHardware("virtio")
 )
+
+// nicHostInterfaceOptions lists, for each NIC network using NIC.HostInterface,
+// the VBoxManage option setting it and its key in the machine readable VM info.
+var nicHostInterfaceOptions = map[NICNetwork]struct{ option, key string }{
+	NICNetHostonly:   {"--hostonlyadapter", "hostonlyadapter"},
+	NICNetBridged:    {"--bridgeadapter", "bridgeadapter"},
+	NICNetNATNetwork: {"--nat-network", "nat-network"},
+	NICNetInternal:   {"--intnet", "intnet"},
+	NICNetGeneric:    {"--nicgenericdrv", "generic"},
+}
+
+// nicArgs returns the 'VBoxManage modifyvm' arguments setting the n-th NIC.
+func nicArgs(n int, nic NIC) ([]string, error) {
+	mac, err := normalizeMAC(nic.MacAddr)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		fmt.Sprintf("--nic%d", n), string(nic.Network),
+		fmt.Sprintf("--nictype%d", n), string(nic.Hardware),
+		fmt.Sprintf("--cableconnected%d", n), "on",
+		fmt.Sprintf("--macaddress%d", n), mac,
+	}
+	if opt, ok := nicHostInterfaceOptions[nic.Network]; ok {
+		args = append(args, fmt.Sprintf("%s%d", opt.option, n), nic.HostInterface)
+	}
+	if nic.BandwidthGroup != "" {
+		args = append(args, fmt.Sprintf("--nicbandwidthgroup%d", n), nic.BandwidthGroup)
+	}
+	switch nic.PromiscMode {
+	case "":
+	case "deny", "allow-vms", "allow-all":
+		args = append(args, fmt.Sprintf("--nicpromisc%d", n), nic.PromiscMode)
+	default:
+		return nil, fmt.Errorf("invalid promiscuous mode %q", nic.PromiscMode)
+	}
+	return args, nil
+}
+
+// normalizeMAC converts a MAC address, e.g. 08:00:27:ee:1d:f7, to the
+// 080027EE1DF7 form of VBoxManage. An empty address becomes "auto".
+func normalizeMAC(mac string) (string, error) {
+	if mac == "" || mac == "auto" {
+		return "auto", nil
+	}
+	normalized := strings.ToUpper(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+	if !reMACAddr.MatchString(normalized) {
+		return "", fmt.Errorf("invalid MAC address %q", mac)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,61 @@
+package virtualbox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeMAC(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"", "auto"},
+		{"auto", "auto"},
+		{"08:00:27:ee:1d:f7", "080027EE1DF7"},
+		{"08-00-27-EE-1D-F7", "080027EE1DF7"},
+		{"0800.27ee.1df7", "080027EE1DF7"},
+		{"080027EE1DF7", "080027EE1DF7"},
+	}
+	for _, tt := range tests {
+		out, err := normalizeMAC(tt.in)
+		if err != nil {
+			t.Errorf("normalizeMAC(%q): %v", tt.in, err)
+		} else if out != tt.out {
+			t.Errorf("normalizeMAC(%q) = %q, want %q", tt.in, out, tt.out)
+		}
+	}
+	for _, in := range []string{"08:00:27:ee:1d", "08:00:27:ee:1d:g7", "08:00:27:ee:1d:f7:00"} {
+		if _, err := normalizeMAC(in); err == nil {
+			t.Errorf("normalizeMAC(%q): expected an error", in)
+		}
+	}
+}
+
+func TestNICArgs(t *testing.T) {
+	args, err := nicArgs(2, NIC{
+		Network:        NICNetBridged,
+		Hardware:       VirtIO,
+		HostInterface:  "en0",
+		BandwidthGroup: "slow-net",
+		PromiscMode:    "allow-all",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"--nic2", "bridged",
+		"--nictype2", "virtio",
+		"--cableconnected2", "on",
+		"--macaddress2", "auto",
+		"--bridgeadapter2", "en0",
+		"--nicbandwidthgroup2", "slow-net",
+		"--nicpromisc2", "allow-all",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("expected %v, got %v", want, args)
+	}
+
+	if _, err := nicArgs(1, NIC{Network: NICNetBridged, PromiscMode: "all"}); err == nil {
+		t.Fatal("expected an error for an invalid promiscuous mode")
+	}
+}
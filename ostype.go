@@ -0,0 +1,120 @@
+package virtualbox
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"sync"
+)
+
+// OSType is a guest OS type known to VirtualBox.
+type OSType struct {
+	ID          string
+	Description string
+	Family      string
+	Is64Bit     bool
+}
+
+// ListOSTypes returns the guest OS types known to VirtualBox. Their IDs are the
+// valid values of Machine.OSType, which GetMachine reads as such even though
+// 'showvminfo' reports their descriptions.
+func ListOSTypes(ctx context.Context) ([]OSType, error) {
+	out, err := Manage().setOpts(withContext(ctx)).runOut("list", "ostypes")
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	var types []OSType
+	var t *OSType
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		key, val := strings.TrimSpace(res[1]), res[2]
+		if key == "ID" {
+			types = append(types, OSType{ID: val})
+			t = &types[len(types)-1]
+			continue
+		}
+		if t == nil {
+			continue
+		}
+		switch key {
+		case "Description":
+			t.Description = val
+		case "Family ID":
+			t.Family = val
+		case "64 bit":
+			t.Is64Bit = val == "true"
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// ValidateOSType tells whether id is a guest OS type known to VirtualBox.
+func ValidateOSType(id string) (bool, error) {
+	types, err := ListOSTypes(context.Background())
+	if err != nil {
+		return false, err
+	}
+	for _, t := range types {
+		if t.ID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// osTypeIDs holds the IDs of the guest OS types by description, listed once by
+// osTypeID.
+type osTypeIDs struct {
+	once sync.Once
+	ids  map[string]string
+}
+
+var (
+	osTypesMu      sync.Mutex
+	currentOSTypes = &osTypeIDs{}
+)
+
+// osTypeID returns the ID of the guest OS type with the given description, as
+// reported by 'showvminfo'. It only lists the OS types once, until the manager
+// changes, and returns the description unchanged when it cannot be resolved.
+func osTypeID(description string) string {
+	if description == "" {
+		return ""
+	}
+	osTypesMu.Lock()
+	c := currentOSTypes
+	osTypesMu.Unlock()
+	c.once.Do(func() {
+		c.ids = map[string]string{}
+		types, err := ListOSTypes(context.Background())
+		if err != nil {
+			Debug("listing OS types: %v", err)
+			return
+		}
+		for _, t := range types {
+			c.ids[t.Description] = t.ID
+		}
+	})
+	if id, ok := c.ids[description]; ok {
+		return id
+	}
+	return description
+}
+
+// resetOSTypes makes osTypeID list the guest OS types again.
+func resetOSTypes() {
+	osTypesMu.Lock()
+	currentOSTypes = &osTypeIDs{}
+	osTypesMu.Unlock()
+}
@@ -0,0 +1,62 @@
+package virtualbox
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListOSTypes(t *testing.T) {
+	Setup(t)
+
+	// Setup lists the OS types of vboxmanage-list-ostypes-1.out.
+	types, err := ListOSTypes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", types)
+	if len(types) == 0 {
+		t.Fatal("no OS type listed")
+	}
+	if ManageMock != nil {
+		if len(types) != 5 {
+			t.Fatalf("expected 5 OS types, got %d", len(types))
+		}
+		if ubuntu := types[3]; ubuntu.ID != "Ubuntu_64" || ubuntu.Description != "Ubuntu (64-bit)" || ubuntu.Family != "Linux" || !ubuntu.Is64Bit {
+			t.Fatalf("unexpected OS type %+v", ubuntu)
+		}
+		if types[0].Is64Bit {
+			t.Fatalf("unexpected OS type %+v", types[0])
+		}
+	}
+
+	ok, err := ValidateOSType("Ubuntu_64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("Ubuntu_64 should be a valid OS type")
+	}
+
+	Teardown()
+}
+
+func TestValidateMachineOSType(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+	}
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := ValidateOSType(m.OSType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatalf("%q should be a valid OS type", m.OSType)
+	}
+}
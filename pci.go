@@ -0,0 +1,50 @@
+package virtualbox
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	rePCIAddr = regexp.MustCompile(`^[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]$`)
+)
+
+// AttachPCIDevice passes the host PCI device at hostAddr through to the
+// machine, at guestAddr in the guest, or at the same address when empty.
+// Addresses are in the bus:device.function form, e.g. 02:00.0. This requires
+// host support for PCI passthrough, and the machine must be powered off.
+func (m *Machine) AttachPCIDevice(hostAddr, guestAddr string) error {
+	if err := m.checkState("attach PCI device", Poweroff, Aborted); err != nil {
+		return err
+	}
+	if err := checkPCIAddr(hostAddr); err != nil {
+		return err
+	}
+	attach := hostAddr
+	if guestAddr != "" {
+		if err := checkPCIAddr(guestAddr); err != nil {
+			return err
+		}
+		attach += "@" + guestAddr
+	}
+	return Manage().run("modifyvm", m.Name, "--pciattach", attach)
+}
+
+// DetachPCIDevice stops passing the host PCI device at hostAddr through to the
+// machine, which must be powered off.
+func (m *Machine) DetachPCIDevice(hostAddr string) error {
+	if err := m.checkState("detach PCI device", Poweroff, Aborted); err != nil {
+		return err
+	}
+	if err := checkPCIAddr(hostAddr); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", m.Name, "--pcidetach", hostAddr)
+}
+
+func checkPCIAddr(addr string) error {
+	if !rePCIAddr.MatchString(addr) {
+		return fmt.Errorf("invalid PCI address %q, expected bus:device.function", addr)
+	}
+	return nil
+}
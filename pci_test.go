@@ -0,0 +1,40 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestPCIDevice(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("PCI passthrough needs host support")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("modifyvm", VM, "--pciattach", "02:00.0@01:05.0").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", VM, "--pciattach", "02:00.1").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", VM, "--pcidetach", "02:00.0").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Poweroff}
+	if err := m.AttachPCIDevice("02:00.0", "01:05.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AttachPCIDevice("02:00.1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DetachPCIDevice("02:00.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AttachPCIDevice("2:0", ""); err == nil {
+		t.Fatal("expected an error for an invalid address")
+	}
+
+	m.State = Running
+	if err := m.AttachPCIDevice("02:00.0", ""); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
@@ -3,6 +3,14 @@ package virtualbox
 import (
 	"fmt"
 	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reNICKey        = regexp.MustCompile(`^nic\d+$`)
+	reForwardingKey = regexp.MustCompile(`^Forwarding\(\d+\)$`)
 )
 
 // PFRule represents a port forwarding rule.
@@ -14,6 +22,12 @@ type PFRule struct {
 	GuestPort uint16
 }
 
+// NamedPFRule is a port forwarding rule along with its name.
+type NamedPFRule struct {
+	Name string
+	Rule PFRule
+}
+
 // PFProto represents the protocol of a port forwarding rule.
 type PFProto string
 
@@ -49,3 +63,53 @@ func grab(r PFRule) (string, string) {
 	}
 	return hostip, guestip
 }
+
+// ParsePFRule parses a named port forwarding rule in the
+// 'name,proto,hostip,hostport,guestip,guestport' form VirtualBox emits, e.g.
+// "ssh,tcp,,2222,,22". It is the inverse of PFRule.Format. Empty IP fields
+// leave the corresponding rule IP nil.
+func ParsePFRule(s string) (name string, rule PFRule, err error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 6 {
+		return "", rule, fmt.Errorf("invalid port forwarding rule %q", s)
+	}
+	switch rule.Proto = PFProto(fields[1]); rule.Proto {
+	case PFTCP, PFUDP:
+	default:
+		return "", rule, fmt.Errorf("invalid protocol %q in port forwarding rule %q", fields[1], s)
+	}
+	if rule.HostIP, err = parsePFRuleIP(fields[2]); err != nil {
+		return "", rule, err
+	}
+	if rule.HostPort, err = parsePFRulePort(fields[3]); err != nil {
+		return "", rule, err
+	}
+	if rule.GuestIP, err = parsePFRuleIP(fields[4]); err != nil {
+		return "", rule, err
+	}
+	if rule.GuestPort, err = parsePFRulePort(fields[5]); err != nil {
+		return "", rule, err
+	}
+	return fields[0], rule, nil
+}
+
+// parsePFRuleIP parses an optional, possibly bracketed, IP address.
+func parsePFRuleIP(s string) (net.IP, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if s == "" {
+		return nil, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q in port forwarding rule", s)
+	}
+	return ip, nil
+}
+
+func parsePFRulePort(s string) (uint16, error) {
+	port, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q in port forwarding rule", s)
+	}
+	return uint16(port), nil
+}
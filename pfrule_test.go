@@ -0,0 +1,62 @@
+package virtualbox
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParsePFRule(t *testing.T) {
+	tests := []struct {
+		in   string
+		name string
+		rule PFRule
+		err  bool
+	}{
+		{
+			in:   "ssh,tcp,,2222,,22",
+			name: "ssh",
+			rule: PFRule{Proto: PFTCP, HostPort: 2222, GuestPort: 22},
+		},
+		{
+			in:   "dns,udp,127.0.0.1,5353,10.0.2.15,53",
+			name: "dns",
+			rule: PFRule{Proto: PFUDP, HostIP: net.ParseIP("127.0.0.1"), HostPort: 5353, GuestIP: net.ParseIP("10.0.2.15"), GuestPort: 53},
+		},
+		{
+			in:   "web6,tcp,::1,8080,fd17:625c:f037:2::15,80",
+			name: "web6",
+			rule: PFRule{Proto: PFTCP, HostIP: net.ParseIP("::1"), HostPort: 8080, GuestIP: net.ParseIP("fd17:625c:f037:2::15"), GuestPort: 80},
+		},
+		{
+			in:   "web6,tcp,[::1],8080,[fd17:625c:f037:2::15],80",
+			name: "web6",
+			rule: PFRule{Proto: PFTCP, HostIP: net.ParseIP("::1"), HostPort: 8080, GuestIP: net.ParseIP("fd17:625c:f037:2::15"), GuestPort: 80},
+		},
+		{in: "ssh,tcp,2222,22", err: true},
+		{in: "ssh,icmp,,2222,,22", err: true},
+		{in: "ssh,tcp,localhost,2222,,22", err: true},
+		{in: "ssh,tcp,,65536,,22", err: true},
+		{in: "ssh,tcp,,2222,,", err: true},
+	}
+	for _, tt := range tests {
+		name, rule, err := ParsePFRule(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParsePFRule(%q): expected an error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePFRule(%q): %v", tt.in, err)
+			continue
+		}
+		if name != tt.name || rule.String() != tt.rule.String() {
+			t.Errorf("ParsePFRule(%q) = %q, %v, want %q, %v", tt.in, name, rule, tt.name, tt.rule)
+		}
+		// Format never brackets IPv6 addresses.
+		if got := name + "," + rule.Format(); !strings.Contains(tt.in, "[") && got != tt.in {
+			t.Errorf("ParsePFRule(%q) does not round-trip: %q", tt.in, got)
+		}
+	}
+}
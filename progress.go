@@ -0,0 +1,52 @@
+package virtualbox
+
+// ProgressFunc is called with the completion percentage of a long operation,
+// as VBoxManage reports it: 0, 10, 20... up to 100.
+type ProgressFunc func(percent int)
+
+// progressWriter parses the '0%...10%...' progress VBoxManage prints, and
+// reports each new percentage to its ProgressFunc. Tokens may be split across
+// writes.
+type progressWriter struct {
+	fn      ProgressFunc
+	digits  []byte
+	percent int // last reported percentage
+}
+
+func newProgressWriter(fn ProgressFunc) *progressWriter {
+	return &progressWriter{fn: fn, percent: -1}
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch {
+		case b >= '0' && b <= '9':
+			pw.digits = append(pw.digits, b)
+		case b == '%' && len(pw.digits) > 0 && len(pw.digits) <= 3:
+			percent := 0
+			for _, d := range pw.digits {
+				percent = percent*10 + int(d-'0')
+			}
+			if percent <= 100 && percent > pw.percent {
+				pw.percent = percent
+				pw.fn(percent)
+			}
+			pw.digits = pw.digits[:0]
+		default:
+			pw.digits = pw.digits[:0]
+		}
+	}
+	return len(p), nil
+}
+
+// withProgress reports the progress printed by the command to fn, in addition
+// to the output writer of the command, if any.
+func withProgress(fn ProgressFunc) option {
+	return func(cmd Command) {
+		if fn == nil {
+			return
+		}
+		vbcmd := cmd.(*command)
+		vbcmd.output = teeWriter(newProgressWriter(fn), vbcmd.output)
+	}
+}
@@ -0,0 +1,20 @@
+package virtualbox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProgressWriter(t *testing.T) {
+	var percents []int
+	pw := newProgressWriter(func(percent int) { percents = append(percents, percent) })
+	for _, chunk := range []string{"0%...10%", "...2", "0%...30%...", "30%...40%...50%...60%...70%...80%...90%...100%\n", "Successfully imported 1 item.\n"} {
+		if _, err := pw.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+	if !reflect.DeepEqual(percents, want) {
+		t.Fatalf("expected %v, got %v", want, percents)
+	}
+}
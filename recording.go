@@ -0,0 +1,87 @@
+package virtualbox
+
+import (
+	"fmt"
+)
+
+// RecordingConfig holds the screen recording settings of a machine.
+type RecordingConfig struct {
+	Enabled  bool   // whether the machine records its screen, set by StartRecording
+	File     string // path of the WebM video file
+	VideoRes string // video resolution, e.g. "1024x768"
+	FPS      uint   // video frame rate, default when 0
+	MaxTime  uint   // recording time limit (in seconds), unlimited when 0
+}
+
+// StartRecording records the screen of the machine with the given settings.
+// A running machine starts recording immediately, otherwise recording starts
+// when the machine is started.
+func (m *Machine) StartRecording(cfg RecordingConfig) error {
+	cfg.Enabled = true
+	var err error
+	switch m.State {
+	case Running, Paused:
+		err = m.controlRecording(cfg)
+	default:
+		args := []string{"modifyvm", m.Name, "--recording", "on"}
+		if cfg.File != "" {
+			args = append(args, "--recording-file", cfg.File)
+		}
+		if cfg.VideoRes != "" {
+			args = append(args, "--recording-video-res", cfg.VideoRes)
+		}
+		if cfg.FPS > 0 {
+			args = append(args, "--recording-video-fps", fmt.Sprintf("%d", cfg.FPS))
+		}
+		if cfg.MaxTime > 0 {
+			args = append(args, "--recording-max-time", fmt.Sprintf("%d", cfg.MaxTime))
+		}
+		err = Manage().run(args...)
+	}
+	if err != nil {
+		return err
+	}
+	m.Recording = cfg
+	return nil
+}
+
+// controlRecording changes the recording settings of a running machine one
+// at a time, as 'controlvm' accepts a single setting per call.
+func (m *Machine) controlRecording(cfg RecordingConfig) error {
+	var settings [][]string
+	if cfg.File != "" {
+		settings = append(settings, []string{"filename", cfg.File})
+	}
+	if cfg.VideoRes != "" {
+		settings = append(settings, []string{"videores", cfg.VideoRes})
+	}
+	if cfg.FPS > 0 {
+		settings = append(settings, []string{"videofps", fmt.Sprintf("%d", cfg.FPS)})
+	}
+	if cfg.MaxTime > 0 {
+		settings = append(settings, []string{"maxtime", fmt.Sprintf("%d", cfg.MaxTime)})
+	}
+	settings = append(settings, []string{"on"})
+	for _, setting := range settings {
+		if err := Manage().run(append([]string{"controlvm", m.Name, "recording"}, setting...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StopRecording stops recording the screen of the machine.
+func (m *Machine) StopRecording() error {
+	var err error
+	switch m.State {
+	case Running, Paused:
+		err = Manage().run("controlvm", m.Name, "recording", "off")
+	default:
+		err = Manage().run("modifyvm", m.Name, "--recording", "off")
+	}
+	if err != nil {
+		return err
+	}
+	m.Recording.Enabled = false
+	return nil
+}
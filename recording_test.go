@@ -0,0 +1,53 @@
+package virtualbox
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestRecording(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("recording would change TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().run("controlvm", "appliance", "recording", "filename", "/tmp/test.webm").Return(nil).Times(1),
+		ManageMock.EXPECT().run("controlvm", "appliance", "recording", "videofps", "30").Return(nil).Times(1),
+		ManageMock.EXPECT().run("controlvm", "appliance", "recording", "on").Return(nil).Times(1),
+		ManageMock.EXPECT().run("controlvm", "appliance", "recording", "off").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", "appliance", "--recording", "on", "--recording-video-res", "800x600", "--recording-max-time", "60").
+			Return(nil).Times(1),
+	)
+
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RecordingConfig{
+		Enabled:  true,
+		File:     "/home/user/VirtualBox VMs/appliance/appliance-screen0.webm",
+		VideoRes: "1024x768",
+		FPS:      25,
+	}
+	if m.Recording != want {
+		t.Fatalf("unexpected recording settings %+v", m.Recording)
+	}
+	if err := m.StartRecording(RecordingConfig{File: "/tmp/test.webm", FPS: 30}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Recording.Enabled {
+		t.Fatal("recording was not stopped")
+	}
+	m.State = Poweroff
+	if err := m.StartRecording(RecordingConfig{VideoRes: "800x600", MaxTime: 60}); err != nil {
+		t.Fatal(err)
+	}
+}
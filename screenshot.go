@@ -0,0 +1,31 @@
+package virtualbox
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// Screenshot saves the current screen of the running machine to outputPath
+// as a PNG image.
+func (m *Machine) Screenshot(outputPath string) error {
+	if err := m.checkState("take screenshot", Running, Paused); err != nil {
+		return err
+	}
+	return Manage().run("controlvm", m.Name, "screenshotpng", outputPath)
+}
+
+// ScreenshotBytes returns the current screen of the running machine as a PNG
+// image.
+func (m *Machine) ScreenshotBytes() ([]byte, error) {
+	f, err := ioutil.TempFile("", "go-virtualbox-*.png")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	if err := m.Screenshot(f.Name()); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(f.Name())
+}
@@ -0,0 +1,36 @@
+package virtualbox
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestScreenshotBytes(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM is not guaranteed to be running")
+	}
+	png := []byte("\x89PNG\r\n\x1a\n")
+	ManageMock.EXPECT().run("controlvm", VM, "screenshotpng", gomock.Any()).DoAndReturn(func(args ...string) error {
+		return ioutil.WriteFile(args[3], png, 0600)
+	}).Times(1)
+
+	m := &Machine{Name: VM, State: Running}
+	b, err := m.ScreenshotBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, png) {
+		t.Fatalf("unexpected screenshot %q", b)
+	}
+	m.State = Poweroff
+	if err := m.Screenshot("screen.png"); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package virtualbox
+
+import (
+	"fmt"
+)
+
+// EnableSecureBoot enables UEFI secure boot in the machine, which requires
+// VirtualBox 7 and an EFI firmware. It enrolls the Microsoft signatures and the
+// Oracle platform key in the UEFI variable store, which must exist, see
+// ResetUEFIVarStore. The machine must be powered off.
+func (m *Machine) EnableSecureBoot() error {
+	if err := m.checkSecureBoot("enable secure boot"); err != nil {
+		return err
+	}
+	for _, op := range []string{"enrollmssignatures", "enrollorclpk"} {
+		if err := Manage().run("modifynvram", m.Name, op); err != nil {
+			return err
+		}
+	}
+	return Manage().run("modifynvram", m.Name, "secureboot", "--enable")
+}
+
+// DisableSecureBoot disables UEFI secure boot in the machine, keeping the
+// enrolled keys. The machine must be powered off.
+func (m *Machine) DisableSecureBoot() error {
+	if err := m.checkSecureBoot("disable secure boot"); err != nil {
+		return err
+	}
+	return Manage().run("modifynvram", m.Name, "secureboot", "--disable")
+}
+
+// ResetUEFIVarStore creates the UEFI variable store of the machine, or resets
+// it when it exists, which deletes the boot entries of the guest. The machine
+// must be powered off.
+func (m *Machine) ResetUEFIVarStore() error {
+	if err := m.checkSecureBoot("reset UEFI variable store"); err != nil {
+		return err
+	}
+	return Manage().run("modifynvram", m.Name, "inituefivarstore")
+}
+
+func (m *Machine) checkSecureBoot(op string) error {
+	switch m.Firmware {
+	case FirmwareEFI, FirmwareEFI32, FirmwareEFI64:
+	default:
+		return fmt.Errorf("cannot %s: machine %q has %s firmware, not EFI", op, m.Name, m.Firmware)
+	}
+	if err := m.checkState(op, Poweroff, Aborted); err != nil {
+		return err
+	}
+	return requireVersion("secure boot", vbox7)
+}
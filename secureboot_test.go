@@ -0,0 +1,49 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSecureBoot(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("secure boot would change the settings of TEST_VM")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().runOut("--version").Return("7.0.8r156879\n", nil).Times(1),
+		ManageMock.EXPECT().run("modifynvram", VM, "inituefivarstore").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifynvram", VM, "enrollmssignatures").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifynvram", VM, "enrollorclpk").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifynvram", VM, "secureboot", "--enable").Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifynvram", VM, "secureboot", "--disable").Return(nil).Times(1),
+		ManageMock.EXPECT().runOut("--version").Return("6.1.26r145957\n", nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Poweroff, Firmware: FirmwareEFI}
+	if err := m.ResetUEFIVarStore(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.EnableSecureBoot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DisableSecureBoot(); err != nil {
+		t.Fatal(err)
+	}
+	resetVersion()
+	if err := m.EnableSecureBoot(); err == nil {
+		t.Fatal("expected an error with VirtualBox 6.1")
+	}
+
+	m.State = Running
+	if err := m.EnableSecureBoot(); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+	m.State, m.Firmware = Poweroff, FirmwareBIOS
+	if err := m.EnableSecureBoot(); err == nil {
+		t.Fatal("expected an error with a BIOS firmware")
+	}
+}
@@ -0,0 +1,115 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxSerialPorts is the number of serial ports a machine can have.
+const MaxSerialPorts = 4
+
+// SerialPortMode is how a serial port of the machine is connected on the host.
+type SerialPortMode string
+
+const (
+	// SerialOff when the serial port is disabled.
+	SerialOff = SerialPortMode("")
+	// SerialDisconnected when the serial port is not connected to anything.
+	SerialDisconnected = SerialPortMode("disconnected")
+	// SerialServer when the serial port creates a named pipe or local socket.
+	SerialServer = SerialPortMode("server")
+	// SerialClient when the serial port connects to an existing named pipe or local socket.
+	SerialClient = SerialPortMode("client")
+	// SerialFile when the serial port output is logged to a file.
+	SerialFile = SerialPortMode("file")
+	// SerialTCP when the serial port listens on a TCP port.
+	SerialTCP = SerialPortMode("tcpserver")
+)
+
+// standard I/O ports and IRQs of COM1 to COM4.
+var serialPortDefaults = [MaxSerialPorts]struct{ iobase, irq uint }{
+	{0x3f8, 4}, {0x2f8, 3}, {0x3e8, 4}, {0x2e8, 3},
+}
+
+// SerialPortConfig holds the settings of a serial port.
+type SerialPortConfig struct {
+	Mode   SerialPortMode
+	Path   string // pipe or socket in server and client mode, log file in file mode
+	Port   int    // TCP port in tcp mode
+	IOBase uint   // standard one of COM<n> when 0
+	IRQ    uint   // standard one of COM<n> when 0
+}
+
+// SetSerialPort sets the n-th serial port, from 1 to MaxSerialPorts. A
+// SerialOff mode disables it.
+func (m *Machine) SetSerialPort(n int, cfg SerialPortConfig) error {
+	if n < 1 || n > MaxSerialPorts {
+		return fmt.Errorf("serial port %d is out of the 1-%d range", n, MaxSerialPorts)
+	}
+	if cfg.Mode == SerialOff {
+		return Manage().run("modifyvm", m.Name, fmt.Sprintf("--uart%d", n), "off")
+	}
+
+	if cfg.IOBase == 0 {
+		cfg.IOBase = serialPortDefaults[n-1].iobase
+	}
+	if cfg.IRQ == 0 {
+		cfg.IRQ = serialPortDefaults[n-1].irq
+	}
+	args := []string{"modifyvm", m.Name,
+		fmt.Sprintf("--uart%d", n), fmt.Sprintf("0x%03x", cfg.IOBase), fmt.Sprintf("%d", cfg.IRQ),
+		fmt.Sprintf("--uartmode%d", n), string(cfg.Mode),
+	}
+	switch cfg.Mode {
+	case SerialDisconnected:
+	case SerialServer, SerialClient, SerialFile:
+		if cfg.Path == "" {
+			return fmt.Errorf("serial port %d in %s mode has no path", n, cfg.Mode)
+		}
+		args = append(args, cfg.Path)
+	case SerialTCP:
+		if cfg.Port <= 0 {
+			return fmt.Errorf("serial port %d in %s mode has no port", n, cfg.Mode)
+		}
+		args = append(args, fmt.Sprintf("%d", cfg.Port))
+	default:
+		return fmt.Errorf("unknown serial port mode %q", cfg.Mode)
+	}
+	return Manage().run(args...)
+}
+
+// parseSerialPort parses the 'uart<n>' and 'uartmode<n>' values of the
+// machine readable VM info, e.g. "0x03f8,4" and "file,/tmp/com1.log".
+func parseSerialPort(uart, uartmode string) (SerialPortConfig, error) {
+	var cfg SerialPortConfig
+	if uart == "off" {
+		return cfg, nil
+	}
+	fields := strings.SplitN(uart, ",", 2)
+	if len(fields) != 2 {
+		return cfg, fmt.Errorf("invalid serial port %q", uart)
+	}
+	iobase, err := strconv.ParseUint(fields[0], 0, 16)
+	if err != nil {
+		return cfg, err
+	}
+	irq, err := strconv.ParseUint(fields[1], 10, 8)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.IOBase, cfg.IRQ = uint(iobase), uint(irq)
+
+	fields = strings.SplitN(uartmode, ",", 2)
+	cfg.Mode = SerialPortMode(fields[0])
+	if len(fields) == 2 {
+		if cfg.Mode == SerialTCP {
+			if cfg.Port, err = strconv.Atoi(fields[1]); err != nil {
+				return cfg, err
+			}
+		} else {
+			cfg.Path = fields[1]
+		}
+	}
+	return cfg, nil
+}
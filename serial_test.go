@@ -0,0 +1,54 @@
+package virtualbox
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSerialPorts(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("changing the serial ports would change TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", "appliance", "--uart1", "0x3f8", "4", "--uartmode1", "server", "/tmp/com1.sock").
+			Return(nil).Times(1),
+		ManageMock.EXPECT().run("modifyvm", "appliance", "--uart2", "off").Return(nil).Times(1),
+	)
+
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SerialPortConfig{
+		{Mode: SerialFile, Path: "/tmp/appliance-com1.log", IOBase: 0x3f8, IRQ: 4},
+		{Mode: SerialTCP, Port: 2023, IOBase: 0x2f8, IRQ: 3},
+		{},
+		{},
+	}
+	if len(m.SerialPorts) != len(want) {
+		t.Fatalf("unexpected serial ports %+v", m.SerialPorts)
+	}
+	for i := range want {
+		if m.SerialPorts[i] != want[i] {
+			t.Fatalf("unexpected serial port %d %+v", i+1, m.SerialPorts[i])
+		}
+	}
+	if err := m.SetSerialPort(1, SerialPortConfig{Mode: SerialServer, Path: "/tmp/com1.sock"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetSerialPort(2, SerialPortConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetSerialPort(3, SerialPortConfig{Mode: SerialFile}); err == nil {
+		t.Fatal("expected an error for a file mode without path")
+	}
+	if err := m.SetSerialPort(5, SerialPortConfig{Mode: SerialDisconnected}); err == nil {
+		t.Fatal("expected an error for serial port 5")
+	}
+}
@@ -0,0 +1,97 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSharedFolderNotExist is returned when the requested shared folder does not exist.
+	ErrSharedFolderNotExist = errors.New("shared folder does not exist")
+)
+
+// SharedFolder is a host directory shared with a machine.
+type SharedFolder struct {
+	Name      string
+	HostPath  string
+	Transient bool // only shared until the machine is powered off
+}
+
+// SharedFolderOptions holds the optional settings of a shared folder.
+type SharedFolderOptions struct {
+	ReadOnly  bool
+	AutoMount bool
+	Transient bool // only share until the machine is powered off
+}
+
+// AddSharedFolder shares the host directory hostPath with the machine under
+// the given name. Transient folders can only be added to a running machine.
+func (m *Machine) AddSharedFolder(name, hostPath string, opts SharedFolderOptions) error {
+	if name == "" {
+		return fmt.Errorf("shared folder name is empty")
+	}
+	if hostPath == "" {
+		return fmt.Errorf("shared folder host path is empty")
+	}
+	if opts.Transient {
+		if err := m.checkState("add transient shared folder", Running, Paused); err != nil {
+			return err
+		}
+	}
+
+	args := []string{"sharedfolder", "add", m.Name, "--name", name, "--hostpath", hostPath}
+	if opts.ReadOnly {
+		args = append(args, "--readonly")
+	}
+	if opts.AutoMount {
+		args = append(args, "--automount")
+	}
+	if opts.Transient {
+		args = append(args, "--transient")
+	}
+	return Manage().run(args...)
+}
+
+// RemoveSharedFolder stops sharing the shared folder with the given name.
+func (m *Machine) RemoveSharedFolder(name string) error {
+	folders, err := m.ListSharedFolders()
+	if err != nil {
+		return err
+	}
+	for _, folder := range folders {
+		if folder.Name != name {
+			continue
+		}
+		args := []string{"sharedfolder", "remove", m.Name, "--name", name}
+		if folder.Transient {
+			args = append(args, "--transient")
+		}
+		return Manage().run(args...)
+	}
+	return ErrSharedFolderNotExist
+}
+
+// ListSharedFolders returns the permanent and transient shared folders of
+// the machine.
+func (m *Machine) ListSharedFolders() ([]SharedFolder, error) {
+	propMap, err := vmInfo(m.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	folders := []SharedFolder{}
+	for _, mapping := range []string{"Machine", "Transient"} {
+		for i := 1; ; i++ {
+			name, ok := propMap[fmt.Sprintf("SharedFolderName%sMapping%d", mapping, i)]
+			if !ok {
+				break
+			}
+			folders = append(folders, SharedFolder{
+				Name:      name,
+				HostPath:  propMap[fmt.Sprintf("SharedFolderPath%sMapping%d", mapping, i)],
+				Transient: mapping == "Transient",
+			})
+		}
+	}
+	return folders, nil
+}
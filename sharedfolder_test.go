@@ -0,0 +1,56 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestAddSharedFolder(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().run("sharedfolder", "add", VM, "--name", "src", "--hostpath", "/tmp", "--readonly", "--automount").
+			Return(nil).Times(1)
+	}
+	m := &Machine{Name: VM, State: Poweroff}
+	if err := m.AddSharedFolder("src", "/tmp", SharedFolderOptions{ReadOnly: true, AutoMount: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddSharedFolder("scratch", "/tmp", SharedFolderOptions{Transient: true}); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+
+	Teardown()
+}
+
+func TestListSharedFolders(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("TEST_VM has no shared folders to list")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	gomock.InOrder(
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		ManageMock.EXPECT().run("sharedfolder", "remove", VM, "--name", "scratch", "--transient").Return(nil).Times(1),
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Running}
+	folders, err := m.ListSharedFolders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(folders) != 2 || folders[0].HostPath != "/home/user/src" || folders[0].Transient || !folders[1].Transient {
+		t.Fatalf("unexpected shared folders %+v", folders)
+	}
+	if err := m.RemoveSharedFolder("scratch"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveSharedFolder("missing"); err != ErrSharedFolderNotExist {
+		t.Fatalf("expected ErrSharedFolderNotExist, got %v", err)
+	}
+}
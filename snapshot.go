@@ -0,0 +1,196 @@
+package virtualbox
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	reSnapshotKey      = regexp.MustCompile(`^Snapshot(Name|UUID|Description)((?:-\d+)*)$`)
+	reNoSnapshots      = regexp.MustCompile(`does not have any snapshots`)
+	reSnapshotNotFound = regexp.MustCompile(`Could not find a snapshot|does not have any snapshots`)
+)
+
+var (
+	// ErrSnapshotNotExist is returned when the requested snapshot does not exist.
+	ErrSnapshotNotExist = errors.New("snapshot does not exist")
+)
+
+// TakeSnapshot takes a snapshot of the machine with the given name and
+// description, and returns the UUID of the new snapshot. When live is true,
+// the snapshot is taken without pausing a running machine.
+func (m *Machine) TakeSnapshot(name, description string, live bool) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("snapshot name is empty")
+	}
+	if live && m.State == Saved {
+		return "", fmt.Errorf("cannot take a live snapshot of machine %q in %s state: %w", m.Name, m.State, ErrInvalidState)
+	}
+
+	args := []string{"snapshot", m.Name, "take", name}
+	if description != "" {
+		args = append(args, "--description", description)
+	}
+	if live {
+		args = append(args, "--live")
+	}
+	out, err := Manage().runOut(args...)
+	if err != nil {
+		return "", err
+	}
+	res := reUUID.FindStringSubmatch(out)
+	if res == nil {
+		return "", nil
+	}
+	return res[1], nil
+}
+
+// Snapshot is a node of the snapshot tree of a machine.
+type Snapshot struct {
+	Name        string
+	UUID        string
+	Description string
+	Current     bool
+	Children    []Snapshot
+}
+
+// ListSnapshots returns the snapshot tree of the machine, as a list of root
+// snapshots. A machine without snapshots returns an empty list.
+func (m *Machine) ListSnapshots() ([]Snapshot, error) {
+	stdout, stderr, err := Manage().runOutErr("snapshot", m.Name, "list", "--machinereadable")
+	if reNoSnapshots.MatchString(stdout) || reNoSnapshots.MatchString(stderr) {
+		return []Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	/* Keys are suffixed by the path of the snapshot in the tree, e.g.
+	'SnapshotName-1-2' is the second child of the first child of the root. */
+	var paths []string
+	snapshots := map[string]*Snapshot{}
+	current := ""
+	s := bufio.NewScanner(strings.NewReader(stdout))
+	for s.Scan() {
+		res := reVMInfoLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		key := res[1]
+		if key == "" {
+			key = res[2]
+		}
+		val := res[3]
+		if val == "" {
+			val = res[4]
+		}
+		if key == "CurrentSnapshotUUID" {
+			current = val
+			continue
+		}
+		res = reSnapshotKey.FindStringSubmatch(key)
+		if res == nil {
+			continue
+		}
+		path := res[2]
+		snapshot, ok := snapshots[path]
+		if !ok {
+			snapshot = &Snapshot{}
+			snapshots[path] = snapshot
+			paths = append(paths, path)
+		}
+		switch res[1] {
+		case "Name":
+			snapshot.Name = val
+		case "UUID":
+			snapshot.UUID = val
+		case "Description":
+			snapshot.Description = val
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := snapshots[""]; !ok {
+		return []Snapshot{}, nil
+	}
+
+	children := map[string][]string{}
+	for _, path := range paths {
+		parent := ""
+		if i := strings.LastIndex(path, "-"); i > 0 {
+			parent = path[:i]
+		}
+		if path != "" {
+			children[parent] = append(children[parent], path)
+		}
+	}
+	var build func(path string) Snapshot
+	build = func(path string) Snapshot {
+		snapshot := *snapshots[path]
+		snapshot.Current = current != "" && snapshot.UUID == current
+		for _, child := range children[path] {
+			snapshot.Children = append(snapshot.Children, build(child))
+		}
+		return snapshot
+	}
+	return []Snapshot{build("")}, nil
+}
+
+// RestoreSnapshot restores the machine to the snapshot with the given name or
+// UUID. The machine must be powered off or saved.
+func (m *Machine) RestoreSnapshot(nameOrUUID string) error {
+	return m.restoreSnapshot("restore", nameOrUUID)
+}
+
+// RestoreCurrentSnapshot restores the machine to its current snapshot. The
+// machine must be powered off or saved.
+func (m *Machine) RestoreCurrentSnapshot() error {
+	return m.restoreSnapshot("restorecurrent")
+}
+
+func (m *Machine) restoreSnapshot(args ...string) error {
+	if err := m.checkState("restore snapshot", Poweroff, Saved); err != nil {
+		return err
+	}
+	_, stderr, err := Manage().runOutErr(append([]string{"snapshot", m.Name}, args...)...)
+	if err != nil {
+		if reSnapshotNotFound.MatchString(stderr) {
+			return ErrSnapshotNotExist
+		}
+		return err
+	}
+	return m.Refresh()
+}
+
+// DeleteSnapshot deletes the snapshot with the given name or UUID.
+func (m *Machine) DeleteSnapshot(nameOrUUID string) error {
+	return m.DeleteSnapshotContext(context.Background(), nameOrUUID)
+}
+
+// DeleteSnapshotContext deletes the snapshot with the given name or UUID.
+// Deleting a snapshot merges its disk images, which may take a while, so the
+// operation is bounded by the given context.
+func (m *Machine) DeleteSnapshotContext(ctx context.Context, nameOrUUID string) error {
+	return m.DeleteSnapshotProgress(ctx, nameOrUUID, nil)
+}
+
+// DeleteSnapshotProgress is like DeleteSnapshotContext, and reports the
+// progress of the deletion to the given function.
+func (m *Machine) DeleteSnapshotProgress(ctx context.Context, nameOrUUID string, progress ProgressFunc) error {
+	_, stderr, err := Manage().setOpts(withContext(ctx), withProgress(progress)).runOutErr("snapshot", m.Name, "delete", nameOrUUID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if reSnapshotNotFound.MatchString(stderr) {
+			return ErrSnapshotNotExist
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package virtualbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().runOut("snapshot", VM, "take", "pre-test", "--description", "baseline", "--live").
+			Return("0%...10%...20%...30%...40%...50%...60%...70%...80%...90%...100%\n"+
+				"Snapshot taken. UUID: 5d31a7a0-7a22-4c7a-9c4e-1b3ab2d1f0e4\n", nil).Times(1)
+	}
+	m := &Machine{Name: VM, State: Running}
+	id, err := m.TakeSnapshot("pre-test", "baseline", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("snapshot UUID: %s", id)
+	if ManageMock != nil && id != "5d31a7a0-7a22-4c7a-9c4e-1b3ab2d1f0e4" {
+		t.Fatalf("unexpected snapshot UUID %q", id)
+	}
+
+	m.State = Saved
+	if _, err := m.TakeSnapshot("pre-test", "", true); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+	if _, err := m.TakeSnapshot("", "", false); err == nil {
+		t.Fatal("expected an error for an empty snapshot name")
+	}
+
+	Teardown()
+}
+
+func TestListSnapshots(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		snapshotListOut := ReadTestData("vboxmanage-snapshot-list-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "list", "--machinereadable").Return(snapshotListOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "list", "--machinereadable").
+				Return("This machine does not have any snapshots\n", "", errors.New("exit status 1")).Times(1),
+		)
+	}
+	m := &Machine{Name: VM}
+	snapshots, err := m.ListSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", snapshots)
+	if ManageMock != nil {
+		if len(snapshots) != 1 || snapshots[0].Name != "base" || len(snapshots[0].Children) != 2 {
+			t.Fatalf("unexpected snapshot tree %+v", snapshots)
+		}
+		child := snapshots[0].Children[0]
+		if child.Name != "pre-test" || child.Description != "baseline" || len(child.Children) != 1 {
+			t.Fatalf("unexpected snapshot %+v", child)
+		}
+		if !child.Children[0].Current || child.Current {
+			t.Fatalf("wrong current snapshot in %+v", child)
+		}
+
+		snapshots, err = m.ListSnapshots()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snapshots == nil || len(snapshots) != 0 {
+			t.Fatalf("expected an empty list, got %+v", snapshots)
+		}
+	}
+
+	Teardown()
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "restore", "base").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", "go-virtualbox", "restore", "missing").
+				Return("", "VBoxManage: error: Could not find a snapshot named 'missing'\n", errors.New("exit status 1")).Times(1),
+		)
+	}
+	m := &Machine{Name: VM, State: Poweroff}
+	if err := m.RestoreSnapshot("base"); err != nil {
+		t.Fatal(err)
+	}
+	if ManageMock != nil {
+		if m.State != Saved {
+			t.Fatalf("machine was not refreshed: %+v", m)
+		}
+		if err := m.RestoreSnapshot("missing"); err != ErrSnapshotNotExist {
+			t.Fatalf("expected ErrSnapshotNotExist, got %v", err)
+		}
+	}
+
+	m.State = Running
+	if err := m.RestoreCurrentSnapshot(); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+
+	Teardown()
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	Setup(t)
+
+	// Delete the middle snapshot of the base --> pre-test --> post-test chain.
+	if ManageMock != nil {
+		snapshotListOut := ReadTestData("vboxmanage-snapshot-list-2.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "delete", "pre-test").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "list", "--machinereadable").Return(snapshotListOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "delete", "pre-test").
+				Return("", "VBoxManage: error: Could not find a snapshot named 'pre-test'\n", errors.New("exit status 1")).Times(1),
+		)
+	}
+	m := &Machine{Name: VM}
+	if err := m.DeleteSnapshot("pre-test"); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := m.ListSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || len(snapshots[0].Children) != 1 || snapshots[0].Children[0].Name != "post-test" {
+		t.Fatalf("unexpected snapshot tree %+v", snapshots)
+	}
+	if err := m.DeleteSnapshotContext(context.Background(), "pre-test"); err != ErrSnapshotNotExist {
+		t.Fatalf("expected ErrSnapshotNotExist, got %v", err)
+	}
+
+	Teardown()
+}
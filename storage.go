@@ -1,5 +1,22 @@
 package virtualbox
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reStorageCtlNotFound = regexp.MustCompile(`Could not find a controller named`)
+)
+
+var (
+	// ErrStorageCtlNotExist is returned when the storage controller does not exist.
+	ErrStorageCtlNotExist = errors.New("storage controller does not exist")
+)
+
 // StorageController represents a virtualized storage controller.
 type StorageController struct {
 	SysBus      SystemBus
@@ -59,12 +76,96 @@ const (
         CtrlVirtIO = StorageControllerChipset("VirtIO")
 )
 
+// storageControllerBuses maps each storage controller chipset to its bus.
+var storageControllerBuses = map[StorageControllerChipset]SystemBus{
+	CtrlLSILogic:    SysBusSCSI,
+	CtrlLSILogicSAS: SysBusSAS,
+	CtrlBusLogic:    SysBusSCSI,
+	CtrlIntelAHCI:   SysBusSATA,
+	CtrlPIIX3:       SysBusIDE,
+	CtrlPIIX4:       SysBusIDE,
+	CtrlICH6:        SysBusIDE,
+	CtrlI82078:      SysBusFloppy,
+	CtrlUSB:         SysBusUSB,
+	CtrlNVME:        SysBusPCIE,
+	CtrlVirtIO:      SysBusVirtio,
+}
+
+// StorageControllerInfo describes a storage controller of a machine along
+// with its attached media.
+type StorageControllerInfo struct {
+	Name string
+	StorageController
+	Media []StorageMedium
+}
+
+// StorageControllers returns the storage controllers of the machine and the
+// media attached to them.
+func (m *Machine) StorageControllers() ([]StorageControllerInfo, error) {
+	propMap, err := vmInfo(m.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctls := []StorageControllerInfo{}
+	for i := 0; ; i++ {
+		name, ok := propMap[fmt.Sprintf("storagecontrollername%d", i)]
+		if !ok {
+			break
+		}
+		ctl := StorageControllerInfo{Name: name}
+		/* VBoxManage reports chipsets with their own case, e.g. 'IntelAhci'. */
+		chipset := propMap[fmt.Sprintf("storagecontrollertype%d", i)]
+		ctl.Chipset = StorageControllerChipset(chipset)
+		for c, bus := range storageControllerBuses {
+			if strings.EqualFold(string(c), chipset) {
+				ctl.Chipset, ctl.SysBus = c, bus
+				break
+			}
+		}
+		ports, err := strconv.ParseUint(propMap[fmt.Sprintf("storagecontrollerportcount%d", i)], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		ctl.Ports = uint(ports)
+		ctl.Bootable = propMap[fmt.Sprintf("storagecontrollerbootable%d", i)] == "on"
+
+		/* Media are listed as '<controller>-<port>-<device>', drives with a
+		removable medium also have an '<controller>-IsEjected-<port>-<device>'. */
+		for port := uint(0); port < ctl.Ports; port++ {
+			for device := uint(0); device < 2; device++ {
+				medium, ok := propMap[fmt.Sprintf("%s-%d-%d", name, port, device)]
+				if !ok || medium == "none" {
+					continue
+				}
+				driveType := DriveHDD
+				if ctl.SysBus == SysBusFloppy {
+					driveType = DriveFDD
+				} else if _, ok := propMap[fmt.Sprintf("%s-IsEjected-%d-%d", name, port, device)]; ok {
+					driveType = DriveDVD
+				}
+				ctl.Media = append(ctl.Media, StorageMedium{
+					Port:      port,
+					Device:    device,
+					DriveType: driveType,
+					Medium:    medium,
+				})
+			}
+		}
+		ctls = append(ctls, ctl)
+	}
+	return ctls, nil
+}
+
 // StorageMedium represents the storage medium attached to a storage controller.
 type StorageMedium struct {
-	Port      uint
-	Device    uint
-	DriveType DriveType
-	Medium    string // none|emptydrive|<uuid>|<filename|host:<drive>|iscsi
+	Port           uint
+	Device         uint
+	DriveType      DriveType
+	Medium         string // none|emptydrive|<uuid>|<filename|host:<drive>|iscsi
+	NonRotational  bool   // advertise the disk as an SSD to the guest
+	Hotpluggable   bool
+	BandwidthGroup string // name of the disk bandwidth group limiting the medium, if any
 }
 
 // DriveType represents the hardware type of a drive.
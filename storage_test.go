@@ -0,0 +1,89 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestAttachStorage(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("attaching would change the storage of TEST_VM")
+	}
+	ManageMock.EXPECT().run("storageattach", VM, "--storagectl", "SATA Controller",
+		"--port", "1", "--device", "0", "--type", "hdd", "--medium", "data.vdi",
+		"--nonrotational", "on", "--hotpluggable", "on", "--bandwidthgroup", "slow-disk").Return(nil).Times(1)
+	m := &Machine{Name: VM}
+	err := m.AttachStorage("SATA Controller", StorageMedium{
+		Port:           1,
+		DriveType:      DriveHDD,
+		Medium:         "data.vdi",
+		NonRotational:  true,
+		Hotpluggable:   true,
+		BandwidthGroup: "slow-disk",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetachStorage(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("storageattach", VM, "--storagectl", "IDE Controller",
+				"--port", "1", "--device", "0", "--medium", "none").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("storageattach", VM, "--storagectl", "NVMe Controller",
+				"--port", "0", "--device", "0", "--medium", "none").
+				Return("", "VBoxManage: error: Could not find a controller named 'NVMe Controller'\n", errors.New("exit status 1")).Times(1),
+		)
+	}
+	m := &Machine{Name: VM}
+	if err := m.DetachStorage("IDE Controller", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ManageMock != nil {
+		if err := m.DetachStorage("NVMe Controller", 0, 0); err != ErrStorageCtlNotExist {
+			t.Fatalf("expected ErrStorageCtlNotExist, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestStorageControllers(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+	}
+	m := &Machine{Name: VM}
+	ctls, err := m.StorageControllers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", ctls)
+	if ManageMock != nil {
+		if len(ctls) != 2 {
+			t.Fatalf("expected 2 storage controllers, got %d", len(ctls))
+		}
+		ide, sata := ctls[0], ctls[1]
+		if ide.SysBus != SysBusIDE || ide.Chipset != CtrlPIIX4 || len(ide.Media) != 1 || ide.Media[0].DriveType != DriveDVD {
+			t.Fatalf("unexpected IDE controller %+v", ide)
+		}
+		if sata.SysBus != SysBusSATA || sata.Chipset != CtrlIntelAHCI || sata.Ports != 2 || len(sata.Media) != 2 {
+			t.Fatalf("unexpected SATA controller %+v", sata)
+		}
+		if medium := sata.Media[1]; medium.Port != 1 || medium.DriveType != DriveHDD || medium.Medium != "/home/user/VirtualBox VMs/appliance/data.vdi" {
+			t.Fatalf("unexpected SATA medium %+v", medium)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,46 @@
+package virtualbox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultMachineFolder returns the folder where machines are created when no
+// base folder is given, e.g. by CreateMachine.
+func DefaultMachineFolder(ctx context.Context) (string, error) {
+	out, err := Manage().setOpts(withContext(ctx)).runOut("list", "systemproperties")
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res != nil && res[1] == "Default machine folder" {
+			return strings.TrimSpace(res[2]), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("could not find the default machine folder")
+}
+
+// SetDefaultMachineFolder changes the folder where machines are created when
+// no base folder is given.
+func SetDefaultMachineFolder(ctx context.Context, path string) error {
+	if path == "" {
+		return fmt.Errorf("default machine folder is empty")
+	}
+	if err := Manage().setOpts(withContext(ctx)).run("setproperty", "machinefolder", path); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
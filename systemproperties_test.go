@@ -0,0 +1,38 @@
+package virtualbox
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDefaultMachineFolder(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		systemPropertiesOut := ReadTestData("vboxmanage-list-systemproperties-1.out")
+		ManageMock.EXPECT().runOut("list", "systemproperties").Return(systemPropertiesOut, nil).Times(1)
+	}
+	folder, err := DefaultMachineFolder(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("default machine folder: %s", folder)
+	if ManageMock != nil && folder != "/home/user/VirtualBox VMs" {
+		t.Fatalf("unexpected default machine folder %q", folder)
+	}
+
+	Teardown()
+}
+
+func TestSetDefaultMachineFolder(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("changing the default machine folder would affect the host")
+	}
+	ManageMock.EXPECT().run("setproperty", "machinefolder", "/mnt/big/vms").Return(nil).Times(1)
+	if err := SetDefaultMachineFolder(context.Background(), "/mnt/big/vms"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,42 @@
+package virtualbox
+
+import (
+	"fmt"
+)
+
+// TeleportTo live migrates the running machine to the machine waiting for it
+// on the given host and port, see EnableTeleporter. The password must match
+// the one of the target, and may be empty.
+func (m *Machine) TeleportTo(host string, port int, password string) error {
+	if err := m.checkState("teleport", Running, Paused); err != nil {
+		return err
+	}
+	args := []string{"controlvm", m.Name, "teleport", "--host", host, "--port", fmt.Sprintf("%d", port)}
+	if password == "" {
+		return Manage().run(args...)
+	}
+	return withPasswordFile(password, func(passwordFile string) error {
+		return Manage().run(append(args, "--passwordfile", passwordFile)...)
+	})
+}
+
+// EnableTeleporter makes the machine the target of a teleport: once started,
+// it waits for a machine to be teleported on the given TCP port instead of
+// booting. The password may be empty. The machine must be powered off.
+func (m *Machine) EnableTeleporter(port int, password string) error {
+	if err := m.checkState("enable teleporter", Poweroff, Aborted); err != nil {
+		return err
+	}
+	args := []string{"modifyvm", m.Name, "--teleporter", "on", "--teleporterport", fmt.Sprintf("%d", port)}
+	if password == "" {
+		return Manage().run(args...)
+	}
+	return withPasswordFile(password, func(passwordFile string) error {
+		return Manage().run(append(args, "--teleporterpasswordfile", passwordFile)...)
+	})
+}
+
+// DisableTeleporter makes the machine boot normally again.
+func (m *Machine) DisableTeleporter() error {
+	return Manage().run("modifyvm", m.Name, "--teleporter", "off")
+}
@@ -0,0 +1,54 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestTeleportTo(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("teleporting needs a second host")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("controlvm", VM, "teleport", "--host", "target.example.com", "--port", "6000",
+			"--passwordfile", gomock.Any()).DoAndReturn(expectPasswordFile(t, 8, "s3cret")).Times(1),
+		ManageMock.EXPECT().run("controlvm", VM, "teleport", "--host", "target.example.com", "--port", "6000").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Running}
+	if err := m.TeleportTo("target.example.com", 6000, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.TeleportTo("target.example.com", 6000, ""); err != nil {
+		t.Fatal(err)
+	}
+	m.State = Poweroff
+	if err := m.TeleportTo("target.example.com", 6000, ""); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestEnableTeleporter(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("enabling the teleporter would prevent TEST_VM from booting")
+	}
+	gomock.InOrder(
+		ManageMock.EXPECT().run("modifyvm", VM, "--teleporter", "on", "--teleporterport", "6000",
+			"--teleporterpasswordfile", gomock.Any()).DoAndReturn(expectPasswordFile(t, 7, "s3cret")).Times(1),
+		ManageMock.EXPECT().run("modifyvm", VM, "--teleporter", "off").Return(nil).Times(1),
+	)
+	m := &Machine{Name: VM, State: Poweroff}
+	if err := m.EnableTeleporter(6000, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DisableTeleporter(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,35 @@
+package virtualbox
+
+// TPMType is the Trusted Platform Module exposed to the guest.
+type TPMType string
+
+const (
+	// TPMNone is a TPMType value.
+	TPMNone = TPMType("none")
+	// TPM12 is a TPMType value.
+	TPM12 = TPMType("1.2")
+	// TPM20 is a TPMType value, required by Windows 11 guests.
+	TPM20 = TPMType("2.0")
+	// TPMHost is a TPMType value, passing through the TPM of the host.
+	TPMHost = TPMType("host")
+	// TPMSwtpm is a TPMType value, using the swtpm emulator at TPMLocation.
+	TPMSwtpm = TPMType("swtpm")
+)
+
+// vbox7 is VirtualBox 7, the first version supporting a TPM and secure boot.
+var vbox7 = Version{Major: 7}
+
+// parseTPMType converts the TPM type reported by 'showvminfo', e.g. 'v2_0',
+// to a TPMType. 'none', reported by VirtualBox 7 for any machine without TPM,
+// becomes "" so that Modify leaves the TPM unchanged.
+func parseTPMType(s string) TPMType {
+	switch s {
+	case "none":
+		return ""
+	case "v1_2":
+		return TPM12
+	case "v2_0":
+		return TPM20
+	}
+	return TPMType(s)
+}
@@ -0,0 +1,84 @@
+package virtualbox
+
+import (
+	"fmt"
+)
+
+// USBControllerType is the type of a USB controller.
+type USBControllerType string
+
+const (
+	// USBOHCI is a USB 1.1 controller.
+	USBOHCI = USBControllerType("ohci")
+	// USBEHCI is a USB 2.0 controller.
+	USBEHCI = USBControllerType("ehci")
+	// USBXHCI is a USB 3.0 controller.
+	USBXHCI = USBControllerType("xhci")
+)
+
+// USBFilter selects the host USB devices attached to a machine.
+type USBFilter struct {
+	Name      string
+	VendorID  string // hexadecimal vendor ID, e.g. "0529"
+	ProductID string // hexadecimal product ID, e.g. "0001"
+	Remote    bool   // match devices of remote VRDE clients instead of local ones
+}
+
+// usbControllerNames are the names VirtualBox gives the USB controllers.
+var usbControllerNames = map[USBControllerType]string{
+	USBOHCI: "OHCI",
+	USBEHCI: "EHCI",
+	USBXHCI: "xHCI",
+}
+
+// AddUSBController enables the USB controller of the given type. VBoxManage
+// cannot name USB controllers, so name must be empty or the name VirtualBox
+// gives controllers of the type: OHCI, EHCI or xHCI.
+func (m *Machine) AddUSBController(name string, typ USBControllerType) error {
+	defaultName, ok := usbControllerNames[typ]
+	if !ok {
+		return fmt.Errorf("unknown USB controller type %q", typ)
+	}
+	if name != "" && name != defaultName {
+		return fmt.Errorf("USB controller of type %q cannot be named %q, only %q", typ, name, defaultName)
+	}
+	return Manage().run("modifyvm", m.Name, "--usb"+string(typ), "on")
+}
+
+// AddUSBFilter appends a USB device filter to the filters of the machine. The
+// machine is refreshed first to append after the filters it already has.
+func (m *Machine) AddUSBFilter(filter USBFilter) error {
+	if filter.Name == "" {
+		return fmt.Errorf("USB filter name is empty")
+	}
+	if err := m.Refresh(); err != nil {
+		return err
+	}
+	index := len(m.USBFilters)
+	args := []string{"usbfilter", "add", fmt.Sprintf("%d", index), "--target", m.Name, "--name", filter.Name}
+	if filter.VendorID != "" {
+		args = append(args, "--vendorid", filter.VendorID)
+	}
+	if filter.ProductID != "" {
+		args = append(args, "--productid", filter.ProductID)
+	}
+	if filter.Remote {
+		args = append(args, "--remote", "yes")
+	}
+	if err := Manage().run(args...); err != nil {
+		return err
+	}
+	m.USBFilters = append(m.USBFilters, filter)
+	return nil
+}
+
+// RemoveUSBFilter removes the USB device filter at the given zero-based index.
+func (m *Machine) RemoveUSBFilter(index int) error {
+	if err := Manage().run("usbfilter", "remove", fmt.Sprintf("%d", index), "--target", m.Name); err != nil {
+		return err
+	}
+	if index >= 0 && index < len(m.USBFilters) {
+		m.USBFilters = append(m.USBFilters[:index], m.USBFilters[index+1:]...)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package virtualbox
+
+import (
+	"testing"
+)
+
+func TestUSBFilters(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("adding USB filters would change TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+	ManageMock.EXPECT().runOutErr("showvminfo", "appliance", "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+	ManageMock.EXPECT().run("modifyvm", "appliance", "--usbxhci", "on").Return(nil).Times(1)
+	ManageMock.EXPECT().run("usbfilter", "add", "1", "--target", "appliance", "--name", "remote", "--vendorid", "046d", "--remote", "yes").
+		Return(nil).Times(2)
+	ManageMock.EXPECT().run("usbfilter", "remove", "0", "--target", "appliance").Return(nil).Times(1)
+
+	m, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.USBFilters) != 1 || m.USBFilters[0] != (USBFilter{Name: "dongle", VendorID: "0529", ProductID: "0001"}) {
+		t.Fatalf("unexpected USB filters %+v", m.USBFilters)
+	}
+	if err := m.AddUSBController("xHCI", USBXHCI); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddUSBController("", "usb4"); err == nil {
+		t.Fatal("expected an error for an unknown USB controller type")
+	}
+	if err := m.AddUSBController("dongle", USBXHCI); err == nil {
+		t.Fatal("expected an error for a USB controller name VBoxManage cannot set")
+	}
+	// The filters of an unrefreshed machine are read before appending.
+	if err := (&Machine{Name: "appliance"}).AddUSBFilter(USBFilter{Name: "remote", VendorID: "046d", Remote: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddUSBFilter(USBFilter{Name: "remote", VendorID: "046d", Remote: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveUSBFilter(0); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.USBFilters) != 1 || m.USBFilters[0].Name != "remote" {
+		t.Fatalf("unexpected USB filters %+v", m.USBFilters)
+	}
+}
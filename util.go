@@ -2,7 +2,9 @@ package virtualbox
 
 import (
 	"context"
+	"io/ioutil"
 	"net"
+	"os"
 )
 
 // ParseIPv4Mask parses IPv4 netmask written in IP form (e.g. 255.255.255.0).
@@ -18,8 +20,38 @@ func ParseIPv4Mask(s string) net.IPMask {
 // Run is a helper method used to execute the commands using the configured
 // VBoxManage path. The command should be omitted and only the arguments
 // should be passed. It will return the stdout, stderr and error if one
-// occured during command execution.
-func Run(_ context.Context, args ...string) (string, string, error) {
-	// TODO: Convert the function so you can pass in the context.
-	return Manage().runOutErr(args...)
+// occured during command execution. The command is killed when ctx is done.
+func Run(ctx context.Context, args ...string) (string, string, error) {
+	return Manage().setOpts(withContext(ctx)).runOutErr(args...)
+}
+
+// runContext runs the command bounded by ctx, and returns the context error
+// when ctx is done before the command completes.
+func runContext(ctx context.Context, args ...string) error {
+	if err := Manage().setOpts(withContext(ctx)).run(args...); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// withPasswordFile writes password to a temporary file only readable by the
+// current user, calls fn with its path, and removes the file. VBoxManage reads
+// passwords from such files, which keeps them out of the process arguments.
+func withPasswordFile(password string, fn func(path string) error) error {
+	f, err := ioutil.TempFile("", "go-virtualbox-password")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(password); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return fn(f.Name())
 }
@@ -2,10 +2,18 @@ package virtualbox
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 )
 
 type option func(Command)
@@ -30,13 +38,35 @@ var (
 	ErrMachineNotExist = errors.New("machine does not exist")
 	// ErrCommandNotFound holds the error message when the VBoxManage commands was not found.
 	ErrCommandNotFound = errors.New("command not found")
+	// ErrVBoxManageNotFound holds the error message when the VBoxManage executable was not found.
+	// It wraps ErrCommandNotFound.
+	ErrVBoxManageNotFound = fmt.Errorf("VBoxManage %w", ErrCommandNotFound)
+	// ErrInvalidState holds the error message when the machine state does not allow the operation.
+	ErrInvalidState = errors.New("invalid machine state")
 )
 
+var (
+	// Running concurrent commands on the same machine, even from different
+	// processes, may fail with 'object is not ready (E_ACCESSDENIED)'.
+	reObjectNotReady = regexp.MustCompile(`object is not ready`)
+)
+
+// readRetries is how many times a command only reading information is retried
+// when VirtualBox is busy, unless WithMaxRetries allows more.
+const readRetries = 3
+
 type command struct {
 	program string
 	sudoer  bool // Is current user a sudoer?
 	sudo    bool // Is current command expected to be run under sudo?
 	guest   bool
+	ctx     context.Context // Context of the current command, if any.
+	output  io.Writer       // Receives the live output of the commands, if any.
+	dryRun  io.Writer       // Receives the command lines instead of running them, if set.
+	logger  CommandLogFunc  // Logs the commands, if set.
+
+	maxRetries   int           // How many times a command is retried when VirtualBox is busy.
+	retryBackoff time.Duration // Delay before the first retry, doubled at each retry.
 }
 
 func (vbcmd command) setOpts(opts ...option) Command {
@@ -55,6 +85,24 @@ func sudo(sudo bool) option {
 	}
 }
 
+func withContext(ctx context.Context) option {
+	return func(cmd Command) {
+		vbcmd := cmd.(*command)
+		vbcmd.ctx = ctx
+	}
+}
+
+// retryReads retries the command like a command only reading information,
+// which can safely be repeated.
+func retryReads() option {
+	return func(cmd Command) {
+		vbcmd := cmd.(*command)
+		if vbcmd.maxRetries < readRetries {
+			vbcmd.maxRetries = readRetries
+		}
+	}
+}
+
 func (vbcmd command) isGuest() bool {
 	return vbcmd.guest
 }
@@ -73,53 +121,166 @@ func (vbcmd command) prepare(args []string) *exec.Cmd {
 	}
 	argv = append(argv, args...)
 	Debug("executing: %v %v", program, argv)
+	if vbcmd.ctx != nil {
+		return exec.CommandContext(vbcmd.ctx, program, argv...) // #nosec
+	}
 	return exec.Command(program, argv...) // #nosec
 }
 
 func (vbcmd command) run(args ...string) error {
 	defer vbcmd.setOpts(sudo(false))
-	cmd := vbcmd.prepare(args)
+	var stdout, stderr io.Writer
 	if Verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		stdout, stderr = os.Stdout, os.Stderr
 	}
-	if err := cmd.Run(); err != nil {
-		if ee, ok := err.(*exec.Error); ok && ee == exec.ErrNotFound {
-			return ErrCommandNotFound
-		}
-		return err
-	}
-	return nil
+	_, _, err := vbcmd.execute(args, stdout, stderr)
+	return err
 }
 
 func (vbcmd command) runOut(args ...string) (string, error) {
 	defer vbcmd.setOpts(sudo(false))
-	cmd := vbcmd.prepare(args)
+	var stderr io.Writer
 	if Verbose {
-		cmd.Stderr = os.Stderr
+		stderr = os.Stderr
 	}
+	stdout, _, err := vbcmd.execute(args, nil, stderr)
+	return stdout, err
+}
 
-	b, err := cmd.Output()
-	if err != nil {
-		if ee, ok := err.(*exec.Error); ok && ee == exec.ErrNotFound {
-			err = ErrCommandNotFound
+func (vbcmd command) runOutErr(args ...string) (string, string, error) {
+	defer vbcmd.setOpts(sudo(false))
+	return vbcmd.execute(args, nil, nil)
+}
+
+// execute runs the command and returns its captured stdout and stderr, also
+// copied to the given writers when not nil. A command exiting with a non-zero
+// status returns a *CommandError. A command failing because VirtualBox was
+// busy with the machine is retried up to maxRetries times.
+func (vbcmd command) execute(args []string, stdoutCopy, stderrCopy io.Writer) (string, string, error) {
+	backoff := vbcmd.retryBackoff
+	for retry := 0; ; retry++ {
+		stdout, stderr, err := vbcmd.executeOnce(args, stdoutCopy, stderrCopy)
+		if err == nil || retry >= vbcmd.maxRetries || !reObjectNotReady.MatchString(stderr) {
+			return stdout, stderr, err
+		}
+		Debug("retrying %v in %v: %v", args, backoff, err)
+		if vbcmd.ctx != nil {
+			select {
+			case <-vbcmd.ctx.Done():
+				return stdout, stderr, err
+			case <-time.After(backoff):
+			}
+		} else {
+			time.Sleep(backoff)
 		}
+		backoff *= 2
 	}
-	return string(b), err
 }
 
-func (vbcmd command) runOutErr(args ...string) (string, string, error) {
-	defer vbcmd.setOpts(sudo(false))
+func (vbcmd command) executeOnce(args []string, stdoutCopy, stderrCopy io.Writer) (string, string, error) {
 	cmd := vbcmd.prepare(args)
+	if vbcmd.dryRun != nil {
+		_, err := fmt.Fprintln(vbcmd.dryRun, commandLine(cmd.Args))
+		return "", "", err
+	}
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	var output io.Writer
+	if vbcmd.output != nil {
+		// stdout and stderr are copied concurrently.
+		output = &lockedWriter{w: vbcmd.output}
+	}
+	cmd.Stdout = teeWriter(&stdout, stdoutCopy, output)
+	cmd.Stderr = teeWriter(&stderr, stderrCopy, output)
+	start := time.Now()
 	err := cmd.Run()
-	if err != nil {
-		if ee, ok := err.(*exec.Error); ok && ee == exec.ErrNotFound {
-			err = ErrCommandNotFound
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		err = &CommandError{
+			Args:     args,
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+			ExitCode: ee.ExitCode(),
+			err:      ee,
 		}
 	}
+	err = vbcmd.notFound(err)
+	if vbcmd.logger != nil {
+		vbcmd.logger(args, time.Since(start), err)
+	}
 	return stdout.String(), stderr.String(), err
 }
+
+// commandLine formats argv as a shell command line.
+func commandLine(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`|&;<>()*?[]#~") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// teeWriter returns a writer duplicating its writes to w and to the given
+// copies that are not nil.
+func teeWriter(w io.Writer, copies ...io.Writer) io.Writer {
+	ws := []io.Writer{w}
+	for _, c := range copies {
+		if c != nil {
+			ws = append(ws, c)
+		}
+	}
+	if len(ws) == 1 {
+		return w
+	}
+	return io.MultiWriter(ws...)
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+// CommandError is returned when a VirtualBox command exits with a non-zero
+// status, along with what the command printed.
+type CommandError struct {
+	Args     []string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	err      error
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("%s: exit status %d", strings.Join(e.Args, " "), e.ExitCode)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// Unwrap returns the underlying *exec.ExitError.
+func (e *CommandError) Unwrap() error {
+	return e.err
+}
+
+// notFound turns the error of a command whose executable could not be
+// located into an ErrVBoxManageNotFound error naming the searched program.
+func (vbcmd command) notFound(err error) error {
+	var pe *os.PathError
+	if errors.Is(err, exec.ErrNotFound) || (errors.As(err, &pe) && errors.Is(pe, os.ErrNotExist)) {
+		if filepath.Base(vbcmd.program) == vbcmd.program {
+			return fmt.Errorf("%w: %s not in $PATH", ErrVBoxManageNotFound, vbcmd.program)
+		}
+		return fmt.Errorf("%w: %s", ErrVBoxManageNotFound, vbcmd.program)
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+package virtualbox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandNotFound(t *testing.T) {
+	for _, program := range []string{"VBoxManage-missing", "/nonexistent/VBoxManage"} {
+		vbcmd := command{program: program}
+		err := vbcmd.run("list", "vms")
+		if !errors.Is(err, ErrVBoxManageNotFound) || !errors.Is(err, ErrCommandNotFound) {
+			t.Fatalf("expected ErrVBoxManageNotFound, got %v", err)
+		}
+		if !strings.Contains(err.Error(), program) {
+			t.Fatalf("searched path %s is missing from %q", program, err)
+		}
+		if _, _, err := vbcmd.runOutErr("list", "vms"); !errors.Is(err, ErrVBoxManageNotFound) {
+			t.Fatalf("expected ErrVBoxManageNotFound, got %v", err)
+		}
+	}
+}
+
+func TestWithVBoxManagePath(t *testing.T) {
+	defer SetManager(manage)
+
+	SetManager(NewManager(WithVBoxManagePath("/opt/VirtualBox/VBoxManage")))
+	if path := Manage().path(); path != "/opt/VirtualBox/VBoxManage" {
+		t.Fatalf("unexpected VBoxManage path %q", path)
+	}
+	if Manage().isGuest() {
+		t.Fatal("VBoxManage is not a guest command")
+	}
+	if _, err := Manage().runOut("list", "vms"); !strings.Contains(err.Error(), "/opt/VirtualBox/VBoxManage") {
+		t.Fatalf("expected an error naming the VBoxManage path, got %v", err)
+	}
+}
+
+func TestCommandError(t *testing.T) {
+	// Run the test binary itself with an unknown flag, which fails with
+	// exit status 2 after printing the usage.
+	vbcmd := command{program: os.Args[0]}
+	_, _, err := vbcmd.runOutErr("-test.unknown")
+	var ce *CommandError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected a CommandError, got %v", err)
+	}
+	if ce.ExitCode != 2 || !strings.Contains(ce.Stderr, "-test.unknown") {
+		t.Fatalf("unexpected CommandError %+v", ce)
+	}
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) {
+		t.Fatalf("CommandError does not wrap the ExitError: %v", err)
+	}
+	if err := vbcmd.run("-test.unknown"); !errors.As(err, &ce) {
+		t.Fatalf("expected a CommandError, got %v", err)
+	}
+}
+
+func TestWithOutputWriter(t *testing.T) {
+	var out bytes.Buffer
+	vbcmd := NewManager(WithVBoxManagePath(os.Args[0]), WithOutputWriter(&out))
+	_, _, err := vbcmd.runOutErr("-test.unknown")
+	var ce *CommandError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected a CommandError, got %v", err)
+	}
+	if !strings.Contains(out.String(), "-test.unknown") {
+		t.Fatalf("output was not streamed: %q", out.String())
+	}
+	if ce.Stderr != out.String() {
+		t.Fatalf("output was not captured: %q", ce.Stderr)
+	}
+}
+
+func TestWithDryRun(t *testing.T) {
+	var out bytes.Buffer
+	vbcmd := NewManager(WithVBoxManagePath("/nonexistent/VBoxManage"), WithDryRun(&out))
+	if err := vbcmd.run("modifyvm", "my vm", "--description", "it's mine", "--cpus", "2"); err != nil {
+		t.Fatal(err)
+	}
+	want := `/nonexistent/VBoxManage modifyvm 'my vm' --description 'it'\''s mine' --cpus 2` + "\n"
+	if out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var logged []string
+	var loggedErr error
+	vbcmd := NewManager(WithVBoxManagePath(os.Args[0]), WithLogger(func(args []string, duration time.Duration, err error) {
+		logged, loggedErr = args, err
+		if duration <= 0 {
+			t.Errorf("unexpected duration %v", duration)
+		}
+	}))
+	_, _, err := vbcmd.runOutErr("-test.unknown")
+	if len(logged) != 1 || logged[0] != "-test.unknown" {
+		t.Fatalf("unexpected logged command %v", logged)
+	}
+	if loggedErr != err {
+		t.Fatalf("expected the logged error to be %v, got %v", err, loggedErr)
+	}
+}
+
+// TestHelperProcess is not a test, but fails like VBoxManage when the machine
+// is busy, until it has run the number of times set in GO_HELPER_FAILURES.
+func TestHelperProcess(t *testing.T) {
+	countFile := os.Getenv("GO_HELPER_COUNT_FILE")
+	if countFile == "" {
+		return
+	}
+	b, _ := ioutil.ReadFile(countFile)
+	count := len(b) + 1
+	if err := ioutil.WriteFile(countFile, bytes.Repeat([]byte("x"), count), 0600); err != nil {
+		os.Exit(3)
+	}
+	if failures, _ := strconv.Atoi(os.Getenv("GO_HELPER_FAILURES")); count <= failures {
+		fmt.Fprintln(os.Stderr, "VBoxManage: error: The object is not ready")
+		fmt.Fprintln(os.Stderr, "VBoxManage: error: Details: code E_ACCESSDENIED (0x80070005)")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-virtualbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv("GO_HELPER_COUNT_FILE")
+	defer os.Unsetenv("GO_HELPER_FAILURES")
+
+	for _, tt := range []struct {
+		failures, maxRetries, runs int
+		read, fails                bool
+	}{
+		{failures: 2, maxRetries: 3, runs: 3},
+		{failures: 2, maxRetries: 1, runs: 2, fails: true},
+		{failures: 2, maxRetries: 0, runs: 1, fails: true},
+		{failures: 2, maxRetries: 0, runs: 3, read: true},
+	} {
+		countFile := filepath.Join(dir, fmt.Sprintf("count-%d-%d-%v", tt.failures, tt.maxRetries, tt.read))
+		os.Setenv("GO_HELPER_COUNT_FILE", countFile)
+		os.Setenv("GO_HELPER_FAILURES", strconv.Itoa(tt.failures))
+		vbcmd := NewManager(WithVBoxManagePath(os.Args[0]), WithMaxRetries(tt.maxRetries), WithRetryBackoff(time.Millisecond))
+		if tt.read {
+			vbcmd = vbcmd.setOpts(retryReads())
+		}
+		err := vbcmd.run("-test.run=TestHelperProcess")
+		if tt.fails != (err != nil) {
+			t.Fatalf("%+v: unexpected error %v", tt, err)
+		}
+		b, err := ioutil.ReadFile(countFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) != tt.runs {
+			t.Fatalf("%+v: expected %d runs, got %d", tt, tt.runs, len(b))
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package virtualbox
 
 import (
+	"io"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"time"
 )
 
 var (
@@ -17,7 +19,10 @@ var (
 	reVMNameUUID      = regexp.MustCompile(`"(.+)" {([0-9a-f-]+)}`)
 	reVMInfoLine      = regexp.MustCompile(`(?:"(.+)"|(.+))=(?:"(.*)"|(.*))`)
 	reColonLine       = regexp.MustCompile(`(.+):\s+(.*)`)
-	reMachineNotFound = regexp.MustCompile(`Could not find a registered machine named '(.+)'`)
+	reUUID            = regexp.MustCompile(`UUID: ([0-9a-f-]+)`)
+	reMachineNotFound = regexp.MustCompile(`Could not find a registered machine (?:named '(.+)'|with UUID \{(.+)\})`)
+	reMachineExist    = regexp.MustCompile(`Machine settings file '.+' already exists`)
+	reSettingsFile    = regexp.MustCompile(`Settings file: '(.+)'`)
 )
 
 // Manage returns the Command to run VBoxManage/VBoxControl.
@@ -25,35 +30,119 @@ func Manage() Command {
 	if manage != nil {
 		return manage
 	}
+	manage = NewManager()
+	return manage
+}
+
+// SetManager sets the Command returned by Manage, which runs the VirtualBox
+// commands of the package.
+func SetManager(cmd Command) {
+	manage = cmd
+	resetVersion()
+	resetOSTypes()
+}
+
+// ManagerOption configures the Command created by NewManager.
+type ManagerOption func(*command)
+
+// WithVBoxManagePath sets the path of the VBoxManage executable, instead of
+// looking it up in the default locations.
+func WithVBoxManagePath(path string) ManagerOption {
+	return func(vbcmd *command) {
+		vbcmd.program = path
+		vbcmd.guest = false
+	}
+}
+
+// WithOutputWriter streams the live output of the commands, both stdout and
+// stderr, to w while they run, e.g. to follow the progress of a long import.
+// The output is still captured for the results and the errors.
+func WithOutputWriter(w io.Writer) ManagerOption {
+	return func(vbcmd *command) {
+		vbcmd.output = w
+	}
+}
+
+// WithDryRun writes the command lines to w instead of running them, e.g. to
+// audit what Machine.Modify would change. The commands succeed without any
+// output, so functions reading information from VirtualBox get nothing or
+// fail to parse it.
+func WithDryRun(w io.Writer) ManagerOption {
+	return func(vbcmd *command) {
+		vbcmd.dryRun = w
+	}
+}
 
+// WithLogger calls log after each command run, e.g. to trace the slow or
+// failing commands.
+func WithLogger(log CommandLogFunc) ManagerOption {
+	return func(vbcmd *command) {
+		vbcmd.logger = log
+	}
+}
+
+// WithMaxRetries sets how many times a command failing because VirtualBox is
+// busy with the machine ('object is not ready (E_ACCESSDENIED)') is retried,
+// e.g. when another process reads the same machine. It defaults to 0, as most
+// commands change something and cannot safely be repeated; reading the
+// machine information is still retried 3 times.
+func WithMaxRetries(n int) ManagerOption {
+	return func(vbcmd *command) {
+		vbcmd.maxRetries = n
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry of a command, which
+// is doubled at each retry. It defaults to 100ms.
+func WithRetryBackoff(d time.Duration) ManagerOption {
+	return func(vbcmd *command) {
+		vbcmd.retryBackoff = d
+	}
+}
+
+// NewManager creates a Command to run VBoxManage/VBoxControl, configured with
+// the given options.
+func NewManager(opts ...ManagerOption) Command {
 	sudoer, err := isSudoer()
 	if err != nil {
 		Debug("Error getting sudoer status: '%v'", err)
 	}
 
+	vbcmd := &command{
+		sudoer:       sudoer,
+		retryBackoff: 100 * time.Millisecond,
+	}
 	if vbprog, err := lookupVBoxProgram("VBoxManage"); err == nil {
-		manage = command{program: vbprog, sudoer: sudoer, guest: false}
+		vbcmd.program = vbprog
 	} else if vbprog, err := lookupVBoxProgram("VBoxControl"); err == nil {
-		manage = command{program: vbprog, sudoer: sudoer, guest: true}
+		vbcmd.program = vbprog
+		vbcmd.guest = true
 	} else {
-		// Did not find a VirtualBox management command
-		manage = command{program: "false", sudoer: false, guest: false}
+		// Did not find a VirtualBox management command, running it reports
+		// an ErrVBoxManageNotFound error.
+		vbcmd.program = vboxProgramPath("VBoxManage")
+		vbcmd.sudoer = false
 	}
-	Debug("manage: '%+v'", manage)
-	return manage
+	for _, opt := range opts {
+		opt(vbcmd)
+	}
+	Debug("manage: '%+v'", *vbcmd)
+	return *vbcmd
 }
 
 func lookupVBoxProgram(vbprog string) (string, error) {
+	return exec.LookPath(vboxProgramPath(vbprog))
+}
 
+// vboxProgramPath returns where to look for the given VirtualBox program.
+func vboxProgramPath(vbprog string) string {
 	if runtime.GOOS == osWindows {
 		if p := os.Getenv("VBOX_INSTALL_PATH"); p != "" {
-			vbprog = filepath.Join(p, vbprog+".exe")
-		} else {
-			vbprog = filepath.Join("C:\\", "Program Files", "Oracle", "VirtualBox", vbprog+".exe")
+			return filepath.Join(p, vbprog+".exe")
 		}
+		return filepath.Join("C:\\", "Program Files", "Oracle", "VirtualBox", vbprog+".exe")
 	}
-
-	return exec.LookPath(vbprog)
+	return vbprog
 }
 
 func isSudoer() (bool, error) {
@@ -29,7 +29,10 @@ func Setup(t *testing.T) {
 	MockCtrl = gomock.NewController(t)
 	if len(VM) < 1 {
 		ManageMock = NewMockCommand(MockCtrl)
-		manage = ManageMock
+		ManageMock.EXPECT().setOpts(gomock.Any()).Return(ManageMock).AnyTimes()
+		// GetMachine resolves the OS type descriptions of showvminfo to IDs.
+		ManageMock.EXPECT().runOut("list", "ostypes").Return(ReadTestData("vboxmanage-list-ostypes-1.out"), nil).AnyTimes()
+		SetManager(ManageMock)
 		t.Logf("Using ManageMock=%v (type=%T)", ManageMock, ManageMock)
 	} else {
 		t.Logf("Using real VM='%s'\n", VM)
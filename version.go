@@ -0,0 +1,110 @@
+package virtualbox
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	reVersion = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(.*)$`)
+)
+
+// Version is a VirtualBox version, e.g. 7.0.8r156879.
+type Version struct {
+	Major  int
+	Minor  int
+	Patch  int
+	Suffix string // build and prerelease information, e.g. "r156879" or "_BETA1r155263"
+}
+
+// String returns the version as printed by 'VBoxManage --version'.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d%s", v.Major, v.Minor, v.Patch, v.Suffix)
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is older than, the same
+// as, or newer than o. Suffixes are ignored.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		} else if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// ParseVersion parses a version as printed by 'VBoxManage --version'.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	res := reVersion.FindStringSubmatch(strings.TrimSpace(s))
+	if res == nil {
+		return v, fmt.Errorf("invalid VirtualBox version %q", s)
+	}
+	v.Major, _ = strconv.Atoi(res[1])
+	v.Minor, _ = strconv.Atoi(res[2])
+	v.Patch, _ = strconv.Atoi(res[3])
+	v.Suffix = res[4]
+	return v, nil
+}
+
+// GetVersion returns the version of VirtualBox.
+func GetVersion(ctx context.Context) (Version, error) {
+	out, err := Manage().setOpts(withContext(ctx)).runOut("--version")
+	if err != nil {
+		if ctx.Err() != nil {
+			return Version{}, ctx.Err()
+		}
+		return Version{}, err
+	}
+	return ParseVersion(out)
+}
+
+// versionResult holds the version of VirtualBox read once by managerVersion.
+type versionResult struct {
+	once sync.Once
+	v    Version
+	err  error
+}
+
+var (
+	versionMu      sync.Mutex
+	currentVersion = &versionResult{}
+)
+
+// managerVersion returns the version of VirtualBox like GetVersion, but only
+// runs 'VBoxManage --version' once, until the manager changes.
+func managerVersion() (Version, error) {
+	versionMu.Lock()
+	r := currentVersion
+	versionMu.Unlock()
+	r.once.Do(func() {
+		r.v, r.err = GetVersion(context.Background())
+	})
+	return r.v, r.err
+}
+
+// resetVersion makes managerVersion read the version of VirtualBox again.
+func resetVersion() {
+	versionMu.Lock()
+	currentVersion = &versionResult{}
+	versionMu.Unlock()
+}
+
+// requireVersion returns an error when VirtualBox is older than min, which the
+// given feature requires.
+func requireVersion(feature string, min Version) error {
+	v, err := managerVersion()
+	if err != nil {
+		return err
+	}
+	if v.Compare(min) < 0 {
+		return fmt.Errorf("%s requires VirtualBox %s or later, found %s", feature, min, v)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package virtualbox
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetVersion(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().runOut("--version").Return("7.0.8r156879\n", nil).Times(1)
+	}
+	v, err := GetVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%s", v)
+	if ManageMock != nil && v != (Version{Major: 7, Minor: 0, Patch: 8, Suffix: "r156879"}) {
+		t.Fatalf("unexpected version %+v", v)
+	}
+
+	Teardown()
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"5.2.44r139111", Version{5, 2, 44, "r139111"}},
+		{"6.1.26_Ubuntur145957", Version{6, 1, 26, "_Ubuntur145957"}},
+		{"7.0.0_BETA1r155263", Version{7, 0, 0, "_BETA1r155263"}},
+		{"7.0.8", Version{7, 0, 8, ""}},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.in)
+		if err != nil {
+			t.Errorf("ParseVersion(%q): %v", tt.in, err)
+		} else if v != tt.want {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.in, v, tt.want)
+		}
+	}
+	if _, err := ParseVersion("VBoxManage: error: ..."); err == nil {
+		t.Error("expected an error for an invalid version")
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	v6 := Version{Major: 6, Minor: 1, Patch: 26}
+	v7 := Version{Major: 7, Minor: 0, Patch: 8}
+	if v6.Compare(v7) != -1 || v7.Compare(v6) != 1 || v7.Compare(Version{7, 0, 8, "r156879"}) != 0 {
+		t.Fatal("unexpected version ordering")
+	}
+}
+
+func TestRequireVersionCached(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("the version of VirtualBox is unknown")
+	}
+	ManageMock.EXPECT().runOut("--version").Return("6.1.26r145957\n", nil).Times(1)
+	if err := requireVersion("TPM", vbox7); err == nil {
+		t.Fatal("expected an error with VirtualBox 6.1")
+	}
+	if err := requireVersion("keyboardputstring", keyboardPutStringVersion); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,31 @@
+package virtualbox
+
+// VRDEConfig holds the VirtualBox Remote Desktop Extension (VRDE) settings
+// of a machine, which make it reachable over RDP.
+type VRDEConfig struct {
+	Enabled  bool
+	Port     string // port or range of ports, e.g. "3389" or "5000-5010"
+	Address  string // host address to listen on, all addresses when empty
+	AuthType string // one of {null|external|guest}
+}
+
+// SetVRDE changes the remote display settings of the machine.
+func (m *Machine) SetVRDE(cfg VRDEConfig) error {
+	args := []string{"modifyvm", m.Name, "--vrde", bool2string(cfg.Enabled)}
+	if cfg.Enabled {
+		if cfg.Port != "" {
+			args = append(args, "--vrdeport", cfg.Port)
+		}
+		if cfg.Address != "" {
+			args = append(args, "--vrdeaddress", cfg.Address)
+		}
+		if cfg.AuthType != "" {
+			args = append(args, "--vrdeauthtype", cfg.AuthType)
+		}
+	}
+	if err := Manage().run(args...); err != nil {
+		return err
+	}
+	m.VRDE = cfg
+	return nil
+}
@@ -0,0 +1,35 @@
+package virtualbox
+
+import (
+	"testing"
+)
+
+func TestSetVRDE(t *testing.T) {
+	Setup(t)
+	defer Teardown()
+
+	if ManageMock == nil {
+		t.Skip("enabling VRDE would change TEST_VM")
+	}
+	vmInfoOut := ReadTestData("vboxmanage-showvminfo-3.out")
+	ManageMock.EXPECT().run("modifyvm", VM, "--vrde", "on", "--vrdeport", "5000-5010", "--vrdeaddress", "127.0.0.1", "--vrdeauthtype", "external").
+		Return(nil).Times(1)
+	ManageMock.EXPECT().run("modifyvm", VM, "--vrde", "off").Return(nil).Times(1)
+	ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+
+	cfg := VRDEConfig{Enabled: true, Port: "5000-5010", Address: "127.0.0.1", AuthType: "external"}
+	m := &Machine{Name: VM}
+	if err := m.SetVRDE(cfg); err != nil {
+		t.Fatal(err)
+	}
+	mm, err := GetMachine(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mm.VRDE != cfg {
+		t.Fatalf("VRDE settings did not round-trip: %+v != %+v", mm.VRDE, cfg)
+	}
+	if err := m.SetVRDE(VRDEConfig{}); err != nil {
+		t.Fatal(err)
+	}
+}